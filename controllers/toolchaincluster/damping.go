@@ -0,0 +1,117 @@
+package toolchaincluster
+
+import (
+	"fmt"
+	"sync"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const clusterStillReadyMsg = "/healthz failed, but the cluster is still considered as ready"
+
+// consecutiveProbes the number of consecutive failed and successful "/healthz" probes of a cluster
+type consecutiveProbes struct {
+	failures  int
+	successes int
+}
+
+// clusterHealthHistory keeps the number of consecutive failed and successful "/healthz" probes of each cluster,
+// since the status of the ToolchainCluster doesn't change until a threshold is reached
+type clusterHealthHistory struct {
+	sync.Mutex
+	probes map[string]consecutiveProbes
+}
+
+var healthHistory = &clusterHealthHistory{
+	probes: map[string]consecutiveProbes{},
+}
+
+// record records the result of the last probe of the given cluster, and returns the number of consecutive failures and successes
+func (h *clusterHealthHistory) record(clusterName string, healthy bool) consecutiveProbes {
+	h.Lock()
+	defer h.Unlock()
+	probes := h.probes[clusterName]
+	if healthy {
+		probes.failures = 0
+		probes.successes++
+	} else {
+		probes.failures++
+		probes.successes = 0
+	}
+	h.probes[clusterName] = probes
+	return probes
+}
+
+// retain forgets the history of all the clusters except those with the given names, and returns the names of the forgotten clusters
+func (h *clusterHealthHistory) retain(clusterNames ...string) []string {
+	h.Lock()
+	defer h.Unlock()
+	retained := make(map[string]consecutiveProbes, len(clusterNames))
+	for _, name := range clusterNames {
+		if probes, ok := h.probes[name]; ok {
+			retained[name] = probes
+		}
+	}
+	var forgotten []string
+	for name := range h.probes {
+		if _, ok := retained[name]; !ok {
+			forgotten = append(forgotten, name)
+		}
+	}
+	h.probes = retained
+	return forgotten
+}
+
+// dampHealthzConditions applies the failure and success thresholds to the conditions returned by the "/healthz" probe:
+// a cluster which was ready stays ready until the number of consecutive failures reaches the failure threshold, and a cluster which was
+// not ready doesn't become ready until the number of consecutive successes reaches the success threshold.
+// When the failure threshold is greater than 1, the number of consecutive failures is recorded in the message of the conditions.
+func (hc *HealthChecker) dampHealthzConditions(previous *toolchainv1alpha1.ToolchainClusterStatus, conditions []toolchainv1alpha1.ToolchainClusterCondition) []toolchainv1alpha1.ToolchainClusterCondition {
+	healthy := cluster.IsReady(&toolchainv1alpha1.ToolchainClusterStatus{Conditions: conditions})
+	probes := healthHistory.record(hc.cachedCluster.Name, healthy)
+	wasReady := cluster.IsReady(previous)
+
+	switch {
+	case !healthy && wasReady && probes.failures < hc.failureThreshold:
+		stillReady := clusterReadyCondition()
+		stillReady.Message = withConsecutiveFailures(clusterStillReadyMsg, probes.failures)
+		return []toolchainv1alpha1.ToolchainClusterCondition{stillReady}
+
+	case healthy && !wasReady && hasHealthzConditions(previous) && probes.successes < hc.successThreshold:
+		if isOffline(previous) {
+			return []toolchainv1alpha1.ToolchainClusterCondition{clusterOfflineCondition()}
+		}
+		return []toolchainv1alpha1.ToolchainClusterCondition{clusterNotReadyCondition(), clusterNotOfflineCondition()}
+
+	case !healthy && hc.failureThreshold > 1:
+		for i := range conditions {
+			conditions[i].Message = withConsecutiveFailures(conditions[i].Message, probes.failures)
+		}
+	}
+	return conditions
+}
+
+func withConsecutiveFailures(message string, failures int) string {
+	return fmt.Sprintf("%s (consecutive failures: %d)", message, failures)
+}
+
+// hasHealthzConditions returns `true` if the given status contains the 'Ready' or the 'Offline' condition
+func hasHealthzConditions(status *toolchainv1alpha1.ToolchainClusterStatus) bool {
+	for _, condition := range status.Conditions {
+		if condition.Type == toolchainv1alpha1.ToolchainClusterReady || condition.Type == toolchainv1alpha1.ToolchainClusterOffline {
+			return true
+		}
+	}
+	return false
+}
+
+func isOffline(status *toolchainv1alpha1.ToolchainClusterStatus) bool {
+	for _, condition := range status.Conditions {
+		if condition.Type == toolchainv1alpha1.ToolchainClusterOffline && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -3,15 +3,17 @@ package toolchaincluster
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/internal/concurrency"
+	commonclient "github.com/codeready-toolchain/toolchain-common/pkg/client"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubeclientset "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,6 +23,15 @@ import (
 
 var logger = logf.Log.WithName("toolchaincluster_healthcheck")
 
+// ToolchainClusterTLSVerificationDisabled means that the TLS certificate of the cluster is not verified,
+// because the ToolchainCluster has the `cluster.InsecureSkipTLSVerifyAnnotation` annotation
+const ToolchainClusterTLSVerificationDisabled toolchainv1alpha1.ToolchainClusterConditionType = "TLSVerificationDisabled"
+
+const (
+	insecureSkipTLSVerifyReason = "InsecureSkipTLSVerify"
+	insecureConnectionMsg       = "WARNING: the TLS certificate of the cluster is not verified, the connection is insecure and must not be used in production"
+)
+
 const (
 	healthzOk              = "/healthz responded with ok"
 	healthzNotOk           = "/healthz responded without ok"
@@ -28,22 +39,113 @@ const (
 	clusterReachableMsg    = "cluster is reachable"
 )
 
-func StartHealthChecks(mgr manager.Manager, namespace string, stopChan <-chan struct{}, period time.Duration) {
+// StartHealthChecks starts the periodic health checks of all the ToolchainClusters in the given namespace
+func StartHealthChecks(mgr manager.Manager, namespace string, stopChan <-chan struct{}, period time.Duration, options ...HealthCheckOption) {
 	logger.Info("starting health checks", "period", period)
 	go wait.Until(func() {
-		updateClusterStatuses(namespace, mgr.GetClient())
+		updateClusterStatuses(namespace, mgr.GetClient(), options...)
 	}, period, stopChan)
 }
 
+type healthCheckConfiguration struct {
+	maxConcurrency   int
+	timeout          time.Duration
+	latencyObserver  LatencyObserver
+	probes           []Probe
+	failureThreshold int
+	successThreshold int
+}
+
+func newHealthCheckConfiguration(options ...HealthCheckOption) healthCheckConfiguration {
+	config := healthCheckConfiguration{
+		maxConcurrency:   concurrency.DefaultMaxConcurrency,
+		timeout:          10 * time.Second,
+		latencyObserver:  func(string, time.Duration) {},
+		failureThreshold: 1,
+		successThreshold: 1,
+	}
+	for _, apply := range options {
+		apply(&config)
+	}
+	return config
+}
+
+// HealthCheckOption an option when checking the health of the ToolchainClusters
+type HealthCheckOption func(*healthCheckConfiguration)
+
+// LatencyObserver is called with the name of the cluster and the duration of its health probe, after each probe
+type LatencyObserver func(clusterName string, latency time.Duration)
+
+// MaxConcurrentHealthChecks sets the maximum number of clusters that are probed concurrently (default: `5`)
+func MaxConcurrentHealthChecks(maxConcurrency int) HealthCheckOption {
+	return func(config *healthCheckConfiguration) {
+		if maxConcurrency > 0 {
+			config.maxConcurrency = maxConcurrency
+		}
+	}
+}
+
+// HealthCheckTimeout sets the timeout of each health probe of each cluster (default: `10s`).
+// A cluster which doesn't respond within this timeout is considered as offline.
+func HealthCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(config *healthCheckConfiguration) {
+		if timeout > 0 {
+			config.timeout = timeout
+		}
+	}
+}
+
+// WithProbes registers the given probes, which are run after the default probe (requesting "/healthz") of each cluster
+func WithProbes(probes ...Probe) HealthCheckOption {
+	return func(config *healthCheckConfiguration) {
+		config.probes = append(config.probes, probes...)
+	}
+}
+
+// ObserveProbeLatency sets the observer of the duration of the health probes
+func ObserveProbeLatency(observer LatencyObserver) HealthCheckOption {
+	return func(config *healthCheckConfiguration) {
+		config.latencyObserver = observer
+	}
+}
+
+// FailureThreshold sets the number of consecutive failures of the "/healthz" probe after which a ready cluster
+// is considered as not ready or offline (default: `1`). When greater than `1`, the number of consecutive failures
+// is recorded in the message of the conditions.
+func FailureThreshold(failures int) HealthCheckOption {
+	return func(config *healthCheckConfiguration) {
+		if failures > 0 {
+			config.failureThreshold = failures
+		}
+	}
+}
+
+// SuccessThreshold sets the number of consecutive successes of the "/healthz" probe after which a cluster which
+// was not ready or offline is considered as ready again (default: `1`)
+func SuccessThreshold(successes int) HealthCheckOption {
+	return func(config *healthCheckConfiguration) {
+		if successes > 0 {
+			config.successThreshold = successes
+		}
+	}
+}
+
 type HealthChecker struct {
 	localClusterClient     client.Client
 	remoteClusterClient    client.Client
-	remoteClusterClientset *kubeclientset.Clientset
+	remoteClusterClientset kubeclientset.Interface
+	cachedCluster          *cluster.CachedToolchainCluster
 	logger                 logr.Logger
+	timeout                time.Duration
+	probes                 []Probe
+	failureThreshold       int
+	successThreshold       int
 }
 
-// updateClusterStatuses checks cluster health and updates status of all ToolchainClusters
-func updateClusterStatuses(namespace string, cl client.Client) {
+// updateClusterStatuses checks cluster health and updates status of all ToolchainClusters.
+// The clusters are probed concurrently, so a slow cluster doesn't delay the status update of the other ones.
+func updateClusterStatuses(namespace string, cl client.Client, options ...HealthCheckOption) {
+	config := newHealthCheckConfiguration(options...)
 	clusters := &toolchainv1alpha1.ToolchainClusterList{}
 	err := cl.List(context.TODO(), clusters, client.InNamespace(namespace))
 	if err != nil {
@@ -53,74 +155,123 @@ func updateClusterStatuses(namespace string, cl client.Client) {
 	if len(clusters.Items) == 0 {
 		logger.Info("no ToolchainCluster found")
 	}
+	clusterNames := make([]string, len(clusters.Items))
+	for i, clusterObj := range clusters.Items {
+		clusterNames[i] = clusterObj.Name
+	}
+	for _, forgotten := range healthHistory.retain(clusterNames...) {
+		deleteClusterMetrics(forgotten)
+	}
 
-	for _, obj := range clusters.Items {
-		clusterObj := obj.DeepCopy()
-		clusterLogger := logger.WithValues("cluster-name", clusterObj.Name)
-
-		cachedCluster, ok := cluster.GetCachedToolchainCluster(clusterObj.Name)
-		if !ok {
-			clusterLogger.Error(fmt.Errorf("cluster %s not found in cache", clusterObj.Name), "failed to retrieve stored data for cluster")
-			clusterObj.Status.Conditions = []toolchainv1alpha1.ToolchainClusterCondition{clusterOfflineCondition()}
-			if err := cl.Status().Update(context.TODO(), clusterObj); err != nil {
-				clusterLogger.Error(err, "failed to update the status of ToolchainCluster")
-			}
-			continue
-		}
+	concurrency.ForEach(len(clusters.Items), config.maxConcurrency, func(index int) {
+		updateClusterStatus(cl, clusters.Items[index].DeepCopy(), config)
+	})
+}
 
-		clientSet, err := kubeclientset.NewForConfig(cachedCluster.Config)
-		if err != nil {
-			clusterLogger.Error(err, "cannot create ClientSet for a ToolchainCluster")
-			continue
-		}
+// updateClusterStatus checks the health and updates the status of the given ToolchainCluster
+func updateClusterStatus(cl client.Client, clusterObj *toolchainv1alpha1.ToolchainCluster, config healthCheckConfiguration) {
+	clusterLogger := logger.WithValues("cluster-name", clusterObj.Name)
 
-		healthChecker := &HealthChecker{
-			localClusterClient:     cl,
-			remoteClusterClient:    cachedCluster.Client,
-			remoteClusterClientset: clientSet,
-			logger:                 clusterLogger,
+	cachedCluster, ok := cluster.GetCachedToolchainCluster(clusterObj.Name)
+	if !ok {
+		clusterLogger.Error(fmt.Errorf("cluster %s not found in cache", clusterObj.Name), "failed to retrieve stored data for cluster")
+		offlineStatus := toolchainv1alpha1.ToolchainClusterStatus{
+			Conditions: []toolchainv1alpha1.ToolchainClusterCondition{clusterOfflineCondition()},
 		}
-		clusterLogger.Info("getting the current state of ToolchainCluster")
-		if err := healthChecker.updateIndividualClusterStatus(clusterObj); err != nil {
-			clusterLogger.Error(err, "unable to update cluster status of ToolchainCluster")
+		// the failure is recorded in the history too, so the metrics are removed with the history when the cluster is deleted
+		healthHistory.record(clusterObj.Name, false)
+		countProbeErrors(clusterObj.Name, offlineStatus.Conditions)
+		setClusterReadyMetric(clusterObj.Name, &offlineStatus)
+		if err := commonclient.UpdateStatusWithRetry(context.TODO(), cl, clusterObj, func(obj runtime.Object) error {
+			obj.(*toolchainv1alpha1.ToolchainCluster).Status.Conditions = offlineStatus.DeepCopy().Conditions
+			return nil
+		}); err != nil {
+			clusterLogger.Error(err, "failed to update the status of ToolchainCluster")
 		}
+		return
+	}
+
+	clientSet, err := cachedCluster.Clientset()
+	if err != nil {
+		clusterLogger.Error(err, "cannot get ClientSet for a ToolchainCluster")
+		return
+	}
+
+	healthChecker := &HealthChecker{
+		localClusterClient:     cl,
+		remoteClusterClient:    cachedCluster.Client,
+		remoteClusterClientset: clientSet,
+		cachedCluster:          cachedCluster,
+		logger:                 clusterLogger,
+		timeout:                config.timeout,
+		probes:                 config.probes,
+		failureThreshold:       config.failureThreshold,
+		successThreshold:       config.successThreshold,
+	}
+	clusterLogger.Info("getting the current state of ToolchainCluster")
+	latency, err := healthChecker.updateIndividualClusterStatus(clusterObj)
+	config.latencyObserver(clusterObj.Name, latency)
+	probeLatencyHistogram.WithLabelValues(clusterObj.Name).Observe(latency.Seconds())
+	if err != nil {
+		clusterLogger.Error(err, "unable to update cluster status of ToolchainCluster")
 	}
 }
 
-func (hc *HealthChecker) updateIndividualClusterStatus(toolchainCluster *toolchainv1alpha1.ToolchainCluster) error {
+// updateIndividualClusterStatus probes the cluster and updates the status of the given ToolchainCluster accordingly.
+// Returns the duration of the probe.
+func (hc *HealthChecker) updateIndividualClusterStatus(toolchainCluster *toolchainv1alpha1.ToolchainCluster) (time.Duration, error) {
 
-	currentClusterStatus := hc.getClusterHealthStatus()
+	start := time.Now()
+	currentClusterStatus := hc.getClusterHealthStatus(&toolchainCluster.Status)
+	setClusterReadyMetric(toolchainCluster.Name, currentClusterStatus)
+	latency := time.Since(start)
+	hc.logger.Info("probed the health of ToolchainCluster", "latency", latency)
 
-	for index, currentCond := range currentClusterStatus.Conditions {
-		for _, previousCond := range toolchainCluster.Status.Conditions {
-			if currentCond.Type == previousCond.Type && currentCond.Status == previousCond.Status {
-				currentClusterStatus.Conditions[index].LastTransitionTime = previousCond.LastTransitionTime
+	// the status is updated with retries, since the ToolchainCluster may have been modified since it was listed
+	if err := commonclient.UpdateStatusWithRetry(context.TODO(), hc.localClusterClient, toolchainCluster, func(obj runtime.Object) error {
+		toolchainCluster := obj.(*toolchainv1alpha1.ToolchainCluster)
+		newClusterStatus := currentClusterStatus.DeepCopy()
+		for index, currentCond := range newClusterStatus.Conditions {
+			for _, previousCond := range toolchainCluster.Status.Conditions {
+				if currentCond.Type == previousCond.Type && currentCond.Status == previousCond.Status {
+					newClusterStatus.Conditions[index].LastTransitionTime = previousCond.LastTransitionTime
+				}
 			}
 		}
+		toolchainCluster.Status = *newClusterStatus
+		return nil
+	}); err != nil {
+		return latency, errors.Wrapf(err, "Failed to update the status of cluster %s", toolchainCluster.Name)
 	}
-
-	toolchainCluster.Status = *currentClusterStatus
-	if err := hc.localClusterClient.Status().Update(context.TODO(), toolchainCluster); err != nil {
-		return errors.Wrapf(err, "Failed to update the status of cluster %s", toolchainCluster.Name)
-	}
-	return nil
+	return latency, nil
 }
 
-// getClusterHealthStatus gets the kubernetes cluster health status by requesting "/healthz"
-func (hc *HealthChecker) getClusterHealthStatus() *toolchainv1alpha1.ToolchainClusterStatus {
+// getClusterHealthStatus gets the kubernetes cluster health status by running the default probe (requesting "/healthz")
+// and all the additional probes registered with the health checker. Each probe contributes its own conditions.
+// Each probe has its own timeout, so the cluster is considered as offline if it doesn't respond to "/healthz" in time.
+// The failure and success thresholds are applied to the conditions of "/healthz", given the previous status of the cluster.
+// A warning condition is added when the TLS certificate of the cluster is not verified.
+func (hc *HealthChecker) getClusterHealthStatus(previous *toolchainv1alpha1.ToolchainClusterStatus) *toolchainv1alpha1.ToolchainClusterStatus {
 	clusterStatus := toolchainv1alpha1.ToolchainClusterStatus{}
-	body, err := hc.remoteClusterClientset.DiscoveryClient.RESTClient().Get().AbsPath("/healthz").Do(context.TODO()).Raw()
-	if err != nil {
-		hc.logger.Error(err, "Failed to do cluster health check for a ToolchainCluster")
-		clusterStatus.Conditions = append(clusterStatus.Conditions, clusterOfflineCondition())
-	} else {
-		if !strings.EqualFold(string(body), "ok") {
-			clusterStatus.Conditions = append(clusterStatus.Conditions, clusterNotReadyCondition(), clusterNotOfflineCondition())
-		} else {
-			clusterStatus.Conditions = append(clusterStatus.Conditions, clusterReadyCondition())
+	target := ProbeTarget{
+		Cluster:   hc.cachedCluster,
+		Client:    hc.remoteClusterClient,
+		Clientset: hc.remoteClusterClientset,
+		Logger:    hc.logger,
+	}
+	for i, probe := range append([]Probe{HealthzProbe}, hc.probes...) {
+		ctx, cancel := context.WithTimeout(context.TODO(), hc.timeout)
+		conditions := probe(ctx, target)
+		cancel()
+		countProbeErrors(hc.cachedCluster.Name, conditions)
+		if i == 0 {
+			conditions = hc.dampHealthzConditions(previous, conditions)
 		}
+		clusterStatus.Conditions = append(clusterStatus.Conditions, conditions...)
+	}
+	if hc.cachedCluster.Config != nil && hc.cachedCluster.Config.Insecure {
+		clusterStatus.Conditions = append(clusterStatus.Conditions, clusterInsecureCondition())
 	}
-
 	return &clusterStatus
 }
 
@@ -148,6 +299,18 @@ func clusterNotReadyCondition() toolchainv1alpha1.ToolchainClusterCondition {
 	}
 }
 
+func clusterInsecureCondition() toolchainv1alpha1.ToolchainClusterCondition {
+	currentTime := metav1.Now()
+	return toolchainv1alpha1.ToolchainClusterCondition{
+		Type:               ToolchainClusterTLSVerificationDisabled,
+		Status:             corev1.ConditionTrue,
+		Reason:             insecureSkipTLSVerifyReason,
+		Message:            insecureConnectionMsg,
+		LastProbeTime:      currentTime,
+		LastTransitionTime: &currentTime,
+	}
+}
+
 func clusterOfflineCondition() toolchainv1alpha1.ToolchainClusterCondition {
 	currentTime := metav1.Now()
 	return toolchainv1alpha1.ToolchainClusterCondition{
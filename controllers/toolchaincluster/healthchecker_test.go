@@ -2,15 +2,25 @@ package toolchaincluster
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/h2non/gock.v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -83,6 +93,29 @@ func TestClusterHealthChecks(t *testing.T) {
 		assertClusterStatus(t, cl, "stable", healthy())
 	})
 
+	t.Run("if the status update conflicts, then it should be retried", func(t *testing.T) {
+		stable, sec := newToolchainCluster("stable", "http://cluster.com", withStatus(offline()))
+
+		cl := test.NewFakeClient(t, stable, sec)
+		resetCache := setupCachedClusters(t, cl, stable)
+		defer resetCache()
+		attempts := 0
+		cl.MockStatusUpdate = func(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+			attempts++
+			if attempts == 1 {
+				return apierrors.NewConflict(schema.GroupResource{Resource: "toolchainclusters"}, "stable", fmt.Errorf("modified"))
+			}
+			return cl.Client.Status().Update(ctx, obj, opts...)
+		}
+
+		// when
+		updateClusterStatuses("test-namespace", cl)
+
+		// then
+		assert.Equal(t, 2, attempts)
+		assertClusterStatus(t, cl, "stable", healthy())
+	})
+
 	t.Run("if the connection cannot be established at beginning, then it should be offline", func(t *testing.T) {
 		stable, sec := newToolchainCluster("failing", "http://failing.com", toolchainv1alpha1.ToolchainClusterStatus{})
 
@@ -96,6 +129,237 @@ func TestClusterHealthChecks(t *testing.T) {
 	})
 }
 
+func TestConcurrentClusterHealthChecks(t *testing.T) {
+	// given
+	// the requests are not mocked by gock, but sent to the test server
+	gock.EnableNetworking()
+	defer gock.DisableNetworking()
+	lock := sync.Mutex{}
+	current, maxCurrent := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			// no API discovery
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		lock.Lock()
+		current++
+		if current > maxCurrent {
+			maxCurrent = current
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			current--
+			lock.Unlock()
+		}()
+		if strings.HasPrefix(r.Host, "127.0.0.1") {
+			// the hung cluster only responds when the client gives up
+			<-r.Context().Done()
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	port := server.URL[strings.LastIndex(server.URL, ":")+1:]
+	hung, sec := newToolchainCluster("hung", server.URL, toolchainv1alpha1.ToolchainClusterStatus{})
+	stable1, _ := newToolchainCluster("stable-1", "http://localhost:"+port, toolchainv1alpha1.ToolchainClusterStatus{})
+	stable2, _ := newToolchainCluster("stable-2", "http://localhost:"+port, toolchainv1alpha1.ToolchainClusterStatus{})
+	stable3, _ := newToolchainCluster("stable-3", "http://localhost:"+port, toolchainv1alpha1.ToolchainClusterStatus{})
+
+	cl := test.NewFakeClient(t, hung, stable1, stable2, stable3, sec)
+	resetCache := setupCachedClusters(t, cl, hung, stable1, stable2, stable3)
+	defer resetCache()
+	latencies := map[string]time.Duration{}
+
+	// when
+	updateClusterStatuses("test-namespace", cl,
+		MaxConcurrentHealthChecks(2),
+		HealthCheckTimeout(500*time.Millisecond),
+		ObserveProbeLatency(func(clusterName string, latency time.Duration) {
+			lock.Lock()
+			defer lock.Unlock()
+			latencies[clusterName] = latency
+		}))
+
+	// then
+	assertClusterStatus(t, cl, "hung", offline())
+	assertClusterStatus(t, cl, "stable-1", healthy())
+	assertClusterStatus(t, cl, "stable-2", healthy())
+	assertClusterStatus(t, cl, "stable-3", healthy())
+	assert.Equal(t, 2, maxCurrent)
+	require.Len(t, latencies, 4)
+	assert.GreaterOrEqual(t, int64(latencies["hung"]), int64(500*time.Millisecond))
+	assert.Less(t, int64(latencies["stable-1"]), int64(500*time.Millisecond))
+}
+
+func TestClusterHealthChecksWithThresholds(t *testing.T) {
+	// given
+	defer gock.Off()
+	gock.New("http://flapping.com").
+		Get("healthz").
+		Times(3).
+		Reply(404)
+	gock.New("http://flapping.com").
+		Get("healthz").
+		Persist().
+		Reply(200).
+		BodyString("ok")
+	flapping, sec := newToolchainCluster("flapping", "http://flapping.com", withStatus(healthy()))
+	cl := test.NewFakeClient(t, flapping, sec)
+	resetCache := setupCachedClusters(t, cl, flapping)
+	defer resetCache()
+	stillReady := func(failures int) toolchainv1alpha1.ToolchainClusterCondition {
+		condition := healthy()
+		condition.Message = fmt.Sprintf("/healthz failed, but the cluster is still considered as ready (consecutive failures: %d)", failures)
+		return condition
+	}
+	thresholds := []HealthCheckOption{FailureThreshold(3), SuccessThreshold(2)}
+
+	t.Run("should stay ready until the failure threshold is reached", func(t *testing.T) {
+		// when
+		updateClusterStatuses("test-namespace", cl, thresholds...)
+
+		// then
+		assertClusterStatus(t, cl, "flapping", stillReady(1))
+
+		// when
+		updateClusterStatuses("test-namespace", cl, thresholds...)
+
+		// then
+		assertClusterStatus(t, cl, "flapping", stillReady(2))
+	})
+
+	t.Run("should be offline when the failure threshold is reached", func(t *testing.T) {
+		// when
+		updateClusterStatuses("test-namespace", cl, thresholds...)
+
+		// then
+		expected := offline()
+		expected.Message = "cluster is not reachable (consecutive failures: 3)"
+		assertClusterStatus(t, cl, "flapping", expected)
+	})
+
+	t.Run("should stay offline until the success threshold is reached", func(t *testing.T) {
+		// when
+		updateClusterStatuses("test-namespace", cl, thresholds...)
+
+		// then
+		assertClusterStatus(t, cl, "flapping", offline())
+	})
+
+	t.Run("should be ready when the success threshold is reached", func(t *testing.T) {
+		// when
+		updateClusterStatuses("test-namespace", cl, thresholds...)
+
+		// then
+		assertClusterStatus(t, cl, "flapping", healthy())
+	})
+}
+
+func TestClusterHealthCheckMetrics(t *testing.T) {
+	// given
+	defer gock.Off()
+	gock.New("http://cluster.com").
+		Get("healthz").
+		Persist().
+		Reply(200).
+		BodyString("ok")
+	gock.New("http://unreachable.com").
+		Get("healthz").
+		Persist().
+		Reply(404)
+	stable, sec := newToolchainCluster("stable", "http://cluster.com", toolchainv1alpha1.ToolchainClusterStatus{})
+	unreachable, _ := newToolchainCluster("unreachable", "http://unreachable.com", toolchainv1alpha1.ToolchainClusterStatus{})
+	cl := test.NewFakeClient(t, stable, unreachable, sec)
+	resetCache := setupCachedClusters(t, cl, stable, unreachable)
+	defer resetCache()
+	errors := testutil.ToFloat64(probeErrorsCounter.WithLabelValues("unreachable", "ClusterNotReachable"))
+
+	// when
+	updateClusterStatuses("test-namespace", cl)
+
+	// then
+	assert.Equal(t, 1.0, testutil.ToFloat64(clusterReadyGauge.WithLabelValues("stable")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(clusterReadyGauge.WithLabelValues("unreachable")))
+	assert.Equal(t, errors+1, testutil.ToFloat64(probeErrorsCounter.WithLabelValues("unreachable", "ClusterNotReachable")))
+	assert.Equal(t, 2, testutil.CollectAndCount(probeLatencyHistogram))
+
+	t.Run("the metrics of a cluster missing from the cache should be set as offline", func(t *testing.T) {
+		// given
+		// the secret doesn't exist, so the cluster cannot be added to the cache
+		missing, _ := test.NewToolchainClusterWithEndpoint("missing", "missing-secret", "http://missing.com", withStatus(healthy()), map[string]string{})
+		err := cl.Create(context.TODO(), missing)
+		require.NoError(t, err)
+		clusterReadyGauge.WithLabelValues("missing").Set(1)
+		errors := testutil.ToFloat64(probeErrorsCounter.WithLabelValues("missing", "ClusterNotReachable"))
+
+		// when
+		updateClusterStatuses("test-namespace", cl)
+
+		// then
+		assertClusterStatus(t, cl, "missing", offline())
+		assert.Equal(t, 0.0, testutil.ToFloat64(clusterReadyGauge.WithLabelValues("missing")))
+		assert.Equal(t, errors+1, testutil.ToFloat64(probeErrorsCounter.WithLabelValues("missing", "ClusterNotReachable")))
+
+		// when the cluster is deleted
+		err = cl.Delete(context.TODO(), missing)
+		require.NoError(t, err)
+		updateClusterStatuses("test-namespace", cl)
+
+		// then
+		assert.Equal(t, 2, testutil.CollectAndCount(clusterReadyGauge))
+	})
+
+	t.Run("the metrics of a deleted cluster should be removed", func(t *testing.T) {
+		// given
+		err := cl.Delete(context.TODO(), unreachable)
+		require.NoError(t, err)
+
+		// when
+		updateClusterStatuses("test-namespace", cl)
+
+		// then
+		assert.Equal(t, 1, testutil.CollectAndCount(clusterReadyGauge))
+		assert.Equal(t, 1, testutil.CollectAndCount(probeLatencyHistogram))
+	})
+}
+
+func TestClusterHealthChecksWithInsecureConnection(t *testing.T) {
+	// given
+	// the transport of an insecure connection is not mocked by gock, so the requests are sent to the test server
+	gock.EnableNetworking()
+	defer gock.DisableNetworking()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			// no API discovery
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	insecure, sec := newToolchainCluster("insecure", server.URL, toolchainv1alpha1.ToolchainClusterStatus{})
+	insecure.Annotations = map[string]string{cluster.InsecureSkipTLSVerifyAnnotation: "true"}
+	cl := test.NewFakeClient(t, insecure, sec)
+	resetCache := setupCachedClusters(t, cl, insecure)
+	defer resetCache()
+
+	// when
+	updateClusterStatuses("test-namespace", cl)
+
+	// then
+	assertClusterStatus(t, cl, "insecure",
+		healthy(),
+		toolchainv1alpha1.ToolchainClusterCondition{
+			Type:    ToolchainClusterTLSVerificationDisabled,
+			Status:  corev1.ConditionTrue,
+			Reason:  "InsecureSkipTLSVerify",
+			Message: "WARNING: the TLS certificate of the cluster is not verified, the connection is insecure and must not be used in production",
+		})
+}
+
 func setupCachedClusters(t *testing.T, cl *test.FakeClient, clusters ...*toolchainv1alpha1.ToolchainCluster) func() {
 	service := cluster.NewToolchainClusterService(cl, logf.Log, "test-namespace", 0)
 	for _, clustr := range clusters {
@@ -109,6 +373,9 @@ func setupCachedClusters(t *testing.T, cl *test.FakeClient, clusters ...*toolcha
 		for _, clustr := range clusters {
 			service.DeleteToolchainCluster(clustr.Name)
 		}
+		for _, forgotten := range healthHistory.retain() {
+			deleteClusterMetrics(forgotten)
+		}
 	}
 }
 
@@ -0,0 +1,65 @@
+package toolchaincluster
+
+import (
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// clusterReadyGauge is `1` if the cluster is ready, `0` otherwise
+	clusterReadyGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "toolchain_cluster_ready",
+		Help: "Whether the ToolchainCluster is ready (1) or not (0)",
+	}, []string{"cluster_name"})
+
+	// probeLatencyHistogram the duration of the health probes of each cluster
+	probeLatencyHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "toolchain_cluster_probe_duration_seconds",
+		Help:    "Duration of the health probes of the ToolchainCluster, in seconds",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"cluster_name"})
+
+	// probeErrorsCounter the number of failed probes of each cluster, by reason
+	probeErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "toolchain_cluster_probe_errors_total",
+		Help: "Number of failed health probes of the ToolchainCluster, by reason",
+	}, []string{"cluster_name", "reason"})
+)
+
+func init() {
+	// the metrics are exposed by the manager, which serves the controller-runtime registry
+	metrics.Registry.MustRegister(clusterReadyGauge, probeLatencyHistogram, probeErrorsCounter)
+}
+
+// countProbeErrors increments the error counter for each condition returned by a probe which reports a failure,
+// ie, an 'Offline' condition which is true or any other condition which is false
+func countProbeErrors(clusterName string, conditions []toolchainv1alpha1.ToolchainClusterCondition) {
+	for _, condition := range conditions {
+		failed := condition.Status == corev1.ConditionFalse
+		if condition.Type == toolchainv1alpha1.ToolchainClusterOffline {
+			failed = condition.Status == corev1.ConditionTrue
+		}
+		if failed {
+			probeErrorsCounter.WithLabelValues(clusterName, condition.Reason).Inc()
+		}
+	}
+}
+
+// setClusterReadyMetric sets the readiness gauge of the given cluster from its status
+func setClusterReadyMetric(clusterName string, status *toolchainv1alpha1.ToolchainClusterStatus) {
+	ready := 0.0
+	if cluster.IsReady(status) {
+		ready = 1
+	}
+	clusterReadyGauge.WithLabelValues(clusterName).Set(ready)
+}
+
+// deleteClusterMetrics removes the readiness and the latency metrics of the given cluster, which doesn't exist anymore.
+// The error counters are kept, since they are totals.
+func deleteClusterMetrics(clusterName string) {
+	clusterReadyGauge.DeleteLabelValues(clusterName)
+	probeLatencyHistogram.DeleteLabelValues(clusterName)
+}
@@ -0,0 +1,190 @@
+package toolchaincluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubeclientset "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The types of the conditions set by the additional probes
+const (
+	// ToolchainClusterAPIServerReady means that all the checks of the "/readyz" endpoint of the API server passed
+	ToolchainClusterAPIServerReady toolchainv1alpha1.ToolchainClusterConditionType = "APIServerReady"
+	// ToolchainClusterAPIDiscoveryAvailable means that the API groups of the cluster can be discovered
+	ToolchainClusterAPIDiscoveryAvailable toolchainv1alpha1.ToolchainClusterConditionType = "APIDiscoveryAvailable"
+	// ToolchainClusterOperatorAvailable means that the deployment of the operator is available in the cluster
+	ToolchainClusterOperatorAvailable toolchainv1alpha1.ToolchainClusterConditionType = "OperatorAvailable"
+	// ToolchainClusterTokenValid means that the token used to access the cluster is valid and has the expected permissions
+	ToolchainClusterTokenValid toolchainv1alpha1.ToolchainClusterConditionType = "TokenValid"
+
+	probeSucceededReason = "ProbeSucceeded"
+	probeFailedReason    = "ProbeFailed"
+	notReachableReason   = "NotReachable"
+	unauthorizedReason   = "Unauthorized"
+	forbiddenReason      = "Forbidden"
+)
+
+// ProbeTarget the cluster to probe
+type ProbeTarget struct {
+	// Cluster the cached data of the cluster
+	Cluster *cluster.CachedToolchainCluster
+	// Client the client of the cluster
+	Client client.Client
+	// Clientset the clientset of the cluster
+	Clientset kubeclientset.Interface
+	// Logger the logger to use for the cluster
+	Logger logr.Logger
+}
+
+// Probe checks an aspect of the health of the target cluster and returns the conditions to set in the status of its ToolchainCluster.
+// The given context is canceled when the timeout of the probe is exceeded.
+type Probe func(ctx context.Context, target ProbeTarget) []toolchainv1alpha1.ToolchainClusterCondition
+
+// HealthzProbe is the default probe: it requests "/healthz" and sets the 'Ready' and 'Offline' conditions
+var HealthzProbe Probe = func(ctx context.Context, target ProbeTarget) []toolchainv1alpha1.ToolchainClusterCondition {
+	body, err := target.Clientset.Discovery().RESTClient().Get().AbsPath("/healthz").Do(ctx).Raw()
+	if err != nil {
+		target.Logger.Error(err, "Failed to do cluster health check for a ToolchainCluster")
+		return []toolchainv1alpha1.ToolchainClusterCondition{clusterOfflineCondition()}
+	}
+	if !strings.EqualFold(string(body), "ok") {
+		return []toolchainv1alpha1.ToolchainClusterCondition{clusterNotReadyCondition(), clusterNotOfflineCondition()}
+	}
+	return []toolchainv1alpha1.ToolchainClusterCondition{clusterReadyCondition()}
+}
+
+// ReadyzProbe requests "/readyz?verbose" and sets the 'APIServerReady' condition, with the names of the failed checks (if any) in its message
+var ReadyzProbe Probe = func(ctx context.Context, target ProbeTarget) []toolchainv1alpha1.ToolchainClusterCondition {
+	// the body contains the verbose output even when the request fails because some checks failed
+	body, err := target.Clientset.Discovery().RESTClient().Get().AbsPath("/readyz").Param("verbose", "").Do(ctx).Raw()
+	if err != nil && len(body) == 0 {
+		return []toolchainv1alpha1.ToolchainClusterCondition{
+			probeCondition(ToolchainClusterAPIServerReady, corev1.ConditionFalse, notReachableReason, err.Error()),
+		}
+	}
+	if failed := failedReadyzChecks(string(body)); len(failed) > 0 {
+		return []toolchainv1alpha1.ToolchainClusterCondition{
+			probeCondition(ToolchainClusterAPIServerReady, corev1.ConditionFalse, probeFailedReason, "failed checks: "+strings.Join(failed, ", ")),
+		}
+	}
+	if err != nil {
+		return []toolchainv1alpha1.ToolchainClusterCondition{
+			probeCondition(ToolchainClusterAPIServerReady, corev1.ConditionFalse, probeFailedReason, err.Error()),
+		}
+	}
+	return []toolchainv1alpha1.ToolchainClusterCondition{
+		probeCondition(ToolchainClusterAPIServerReady, corev1.ConditionTrue, probeSucceededReason, "/readyz checks passed"),
+	}
+}
+
+// failedReadyzChecks returns the names of the failed checks in the given verbose output of "/readyz", ie, the lines such as `[-]etcd failed: reason withheld`
+func failedReadyzChecks(output string) []string {
+	var failed []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "[-]") {
+			continue
+		}
+		name := strings.TrimPrefix(line, "[-]")
+		if i := strings.Index(name, " "); i > 0 {
+			name = name[:i]
+		}
+		failed = append(failed, name)
+	}
+	return failed
+}
+
+// APIDiscoveryProbe discovers the API groups of the cluster and sets the 'APIDiscoveryAvailable' condition
+var APIDiscoveryProbe Probe = func(ctx context.Context, target ProbeTarget) []toolchainv1alpha1.ToolchainClusterCondition {
+	groups := &metav1.APIGroupList{}
+	if err := target.Clientset.Discovery().RESTClient().Get().AbsPath("/apis").Do(ctx).Into(groups); err != nil {
+		return []toolchainv1alpha1.ToolchainClusterCondition{
+			probeCondition(ToolchainClusterAPIDiscoveryAvailable, corev1.ConditionFalse, probeFailedReason, err.Error()),
+		}
+	}
+	return []toolchainv1alpha1.ToolchainClusterCondition{
+		probeCondition(ToolchainClusterAPIDiscoveryAvailable, corev1.ConditionTrue, probeSucceededReason, fmt.Sprintf("%d API groups discovered", len(groups.Groups))),
+	}
+}
+
+// OperatorDeploymentProbe returns a probe which checks that the deployment with the given name is available in the operator namespace
+// of the cluster, and sets the 'OperatorAvailable' condition
+func OperatorDeploymentProbe(deploymentName string) Probe {
+	return func(ctx context.Context, target ProbeTarget) []toolchainv1alpha1.ToolchainClusterCondition {
+		deployment := &appsv1.Deployment{}
+		namespacedName := types.NamespacedName{Namespace: target.Cluster.OperatorNamespace, Name: deploymentName}
+		if err := target.Client.Get(ctx, namespacedName, deployment); err != nil {
+			return []toolchainv1alpha1.ToolchainClusterCondition{
+				probeCondition(ToolchainClusterOperatorAvailable, corev1.ConditionFalse, probeFailedReason,
+					fmt.Sprintf("unable to get the deployment '%s': %s", namespacedName, err.Error())),
+			}
+		}
+		for _, condition := range deployment.Status.Conditions {
+			if condition.Type == appsv1.DeploymentAvailable && condition.Status == corev1.ConditionTrue {
+				return []toolchainv1alpha1.ToolchainClusterCondition{
+					probeCondition(ToolchainClusterOperatorAvailable, corev1.ConditionTrue, probeSucceededReason,
+						fmt.Sprintf("the deployment '%s' is available", namespacedName)),
+				}
+			}
+		}
+		return []toolchainv1alpha1.ToolchainClusterCondition{
+			probeCondition(ToolchainClusterOperatorAvailable, corev1.ConditionFalse, probeFailedReason,
+				fmt.Sprintf("the deployment '%s' is not available", namespacedName)),
+		}
+	}
+}
+
+// TokenValidityProbe returns a probe which checks with a SelfSubjectAccessReview that the token used to access the cluster is valid
+// and allows the given action, and sets the 'TokenValid' condition
+func TokenValidityProbe(attributes authv1.ResourceAttributes) Probe {
+	return func(ctx context.Context, target ProbeTarget) []toolchainv1alpha1.ToolchainClusterCondition {
+		review := &authv1.SelfSubjectAccessReview{
+			Spec: authv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		}
+		if err := target.Client.Create(ctx, review); err != nil {
+			reason := probeFailedReason
+			if apierrors.IsUnauthorized(err) {
+				reason = unauthorizedReason
+			}
+			return []toolchainv1alpha1.ToolchainClusterCondition{
+				probeCondition(ToolchainClusterTokenValid, corev1.ConditionFalse, reason, err.Error()),
+			}
+		}
+		if !review.Status.Allowed {
+			return []toolchainv1alpha1.ToolchainClusterCondition{
+				probeCondition(ToolchainClusterTokenValid, corev1.ConditionFalse, forbiddenReason,
+					fmt.Sprintf("the token doesn't allow to %s %s: %s", attributes.Verb, attributes.Resource, review.Status.Reason)),
+			}
+		}
+		return []toolchainv1alpha1.ToolchainClusterCondition{
+			probeCondition(ToolchainClusterTokenValid, corev1.ConditionTrue, probeSucceededReason,
+				fmt.Sprintf("the token allows to %s %s", attributes.Verb, attributes.Resource)),
+		}
+	}
+}
+
+func probeCondition(conditionType toolchainv1alpha1.ToolchainClusterConditionType, status corev1.ConditionStatus, reason, message string) toolchainv1alpha1.ToolchainClusterCondition {
+	currentTime := metav1.Now()
+	return toolchainv1alpha1.ToolchainClusterCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastProbeTime:      currentTime,
+		LastTransitionTime: &currentTime,
+	}
+}
@@ -0,0 +1,249 @@
+package toolchaincluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+	appsv1 "k8s.io/api/apps/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReadyzProbe(t *testing.T) {
+	// given
+	defer gock.Off()
+	gock.New("http://healthy.com").
+		Get("readyz").
+		MatchParam("verbose", "").
+		Persist().
+		Reply(200).
+		BodyString("[+]ping ok\n[+]etcd ok\nreadyz check passed")
+	gock.New("http://failing-etcd.com").
+		Get("readyz").
+		MatchParam("verbose", "").
+		Persist().
+		Reply(500).
+		BodyString("[+]ping ok\n[-]etcd failed: reason withheld\n[-]informer-sync failed: reason withheld\nreadyz check failed")
+
+	t.Run("all checks passed", func(t *testing.T) {
+		// when
+		conditions := ReadyzProbe(context.TODO(), newProbeTarget(t, "http://healthy.com", test.NewFakeClient(t)))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterAPIServerReady, corev1.ConditionTrue, "ProbeSucceeded", "/readyz checks passed"))
+	})
+
+	t.Run("some checks failed", func(t *testing.T) {
+		// when
+		conditions := ReadyzProbe(context.TODO(), newProbeTarget(t, "http://failing-etcd.com", test.NewFakeClient(t)))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterAPIServerReady, corev1.ConditionFalse, "ProbeFailed", "failed checks: etcd, informer-sync"))
+	})
+}
+
+func TestAPIDiscoveryProbe(t *testing.T) {
+	// given
+	defer gock.Off()
+	gock.New("http://cluster.com").
+		Get("apis").
+		Persist().
+		Reply(200).
+		JSON(metav1.APIGroupList{
+			TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"},
+			Groups:   []metav1.APIGroup{{Name: "apps"}, {Name: "toolchain.dev.openshift.com"}},
+		})
+	gock.New("http://not-found.com").
+		Get("apis").
+		Persist().
+		Reply(404)
+
+	t.Run("API groups discovered", func(t *testing.T) {
+		// when
+		conditions := APIDiscoveryProbe(context.TODO(), newProbeTarget(t, "http://cluster.com", test.NewFakeClient(t)))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterAPIDiscoveryAvailable, corev1.ConditionTrue, "ProbeSucceeded", "2 API groups discovered"))
+	})
+
+	t.Run("API groups not discovered", func(t *testing.T) {
+		// when
+		conditions := APIDiscoveryProbe(context.TODO(), newProbeTarget(t, "http://not-found.com", test.NewFakeClient(t)))
+
+		// then
+		require.Len(t, conditions, 1)
+		assert.Equal(t, ToolchainClusterAPIDiscoveryAvailable, conditions[0].Type)
+		assert.Equal(t, corev1.ConditionFalse, conditions[0].Status)
+		assert.Equal(t, "ProbeFailed", conditions[0].Reason)
+	})
+}
+
+func TestOperatorDeploymentProbe(t *testing.T) {
+	// given
+	newDeployment := func(available corev1.ConditionStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "member-operator", Namespace: "toolchain-member-operator"},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: available}},
+			},
+		}
+	}
+	probe := OperatorDeploymentProbe("member-operator")
+
+	t.Run("deployment is available", func(t *testing.T) {
+		// when
+		conditions := probe(context.TODO(), newProbeTarget(t, "http://cluster.com", test.NewFakeClient(t, newDeployment(corev1.ConditionTrue))))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterOperatorAvailable, corev1.ConditionTrue, "ProbeSucceeded",
+			"the deployment 'toolchain-member-operator/member-operator' is available"))
+	})
+
+	t.Run("deployment is not available", func(t *testing.T) {
+		// when
+		conditions := probe(context.TODO(), newProbeTarget(t, "http://cluster.com", test.NewFakeClient(t, newDeployment(corev1.ConditionFalse))))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterOperatorAvailable, corev1.ConditionFalse, "ProbeFailed",
+			"the deployment 'toolchain-member-operator/member-operator' is not available"))
+	})
+
+	t.Run("deployment is missing", func(t *testing.T) {
+		// when
+		conditions := probe(context.TODO(), newProbeTarget(t, "http://cluster.com", test.NewFakeClient(t)))
+
+		// then
+		require.Len(t, conditions, 1)
+		assert.Equal(t, corev1.ConditionFalse, conditions[0].Status)
+		assert.Contains(t, conditions[0].Message, "unable to get the deployment 'toolchain-member-operator/member-operator'")
+	})
+}
+
+func TestTokenValidityProbe(t *testing.T) {
+	// given
+	probe := TokenValidityProbe(authv1.ResourceAttributes{Verb: "create", Resource: "useraccounts", Group: "toolchain.dev.openshift.com"})
+	newClient := func(t *testing.T, allowed bool, err error) *test.FakeClient {
+		cl := test.NewFakeClient(t)
+		cl.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+			if err != nil {
+				return err
+			}
+			obj.(*authv1.SelfSubjectAccessReview).Status = authv1.SubjectAccessReviewStatus{Allowed: allowed, Reason: "mock reason"}
+			return nil
+		}
+		return cl
+	}
+
+	t.Run("token is valid", func(t *testing.T) {
+		// when
+		conditions := probe(context.TODO(), newProbeTarget(t, "http://cluster.com", newClient(t, true, nil)))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterTokenValid, corev1.ConditionTrue, "ProbeSucceeded", "the token allows to create useraccounts"))
+	})
+
+	t.Run("token doesn't have the permission", func(t *testing.T) {
+		// when
+		conditions := probe(context.TODO(), newProbeTarget(t, "http://cluster.com", newClient(t, false, nil)))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterTokenValid, corev1.ConditionFalse, "Forbidden", "the token doesn't allow to create useraccounts: mock reason"))
+	})
+
+	t.Run("token is invalid", func(t *testing.T) {
+		// when
+		conditions := probe(context.TODO(), newProbeTarget(t, "http://cluster.com", newClient(t, false, apierrors.NewUnauthorized("token expired"))))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterTokenValid, corev1.ConditionFalse, "Unauthorized", "token expired"))
+	})
+
+	t.Run("review cannot be created", func(t *testing.T) {
+		// when
+		conditions := probe(context.TODO(), newProbeTarget(t, "http://cluster.com", newClient(t, false, fmt.Errorf("mock error"))))
+
+		// then
+		assertConditions(t, conditions, probeCondition(ToolchainClusterTokenValid, corev1.ConditionFalse, "ProbeFailed", "mock error"))
+	})
+}
+
+func TestClusterHealthChecksWithProbes(t *testing.T) {
+	// given
+	defer gock.Off()
+	gock.New("http://cluster.com").
+		Get("healthz").
+		Persist().
+		Reply(200).
+		BodyString("ok")
+	gock.New("http://cluster.com").
+		Get("readyz").
+		Persist().
+		Reply(500).
+		BodyString("[+]ping ok\n[-]etcd failed: reason withheld\nreadyz check failed")
+	stable, sec := newToolchainCluster("stable", "http://cluster.com", withStatus(offline()))
+	cl := test.NewFakeClient(t, stable, sec)
+	resetCache := setupCachedClusters(t, cl, stable)
+	defer resetCache()
+	customProbe := func(ctx context.Context, target ProbeTarget) []toolchainv1alpha1.ToolchainClusterCondition {
+		return []toolchainv1alpha1.ToolchainClusterCondition{
+			probeCondition("Custom", corev1.ConditionTrue, "CustomReason", "probed "+target.Cluster.Name),
+		}
+	}
+
+	// when
+	updateClusterStatuses("test-namespace", cl, WithProbes(ReadyzProbe, customProbe))
+
+	// then
+	assertClusterStatus(t, cl, "stable",
+		healthy(),
+		toolchainv1alpha1.ToolchainClusterCondition{
+			Type:    ToolchainClusterAPIServerReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  "ProbeFailed",
+			Message: "failed checks: etcd",
+		},
+		toolchainv1alpha1.ToolchainClusterCondition{
+			Type:    "Custom",
+			Status:  corev1.ConditionTrue,
+			Reason:  "CustomReason",
+			Message: "probed stable",
+		})
+}
+
+func newProbeTarget(t *testing.T, apiEndpoint string, cl client.Client) ProbeTarget {
+	clientset, err := kubeclientset.NewForConfig(&rest.Config{Host: apiEndpoint})
+	require.NoError(t, err)
+	return ProbeTarget{
+		Cluster: &cluster.CachedToolchainCluster{
+			Name:              "member-cluster",
+			OperatorNamespace: "toolchain-member-operator",
+		},
+		Client:    cl,
+		Clientset: clientset,
+		Logger:    logf.Log,
+	}
+}
+
+func assertConditions(t *testing.T, actual []toolchainv1alpha1.ToolchainClusterCondition, expected ...toolchainv1alpha1.ToolchainClusterCondition) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].Type, actual[i].Type)
+		assert.Equal(t, expected[i].Status, actual[i].Status)
+		assert.Equal(t, expected[i].Reason, actual[i].Reason)
+		assert.Equal(t, expected[i].Message, actual[i].Message)
+	}
+}
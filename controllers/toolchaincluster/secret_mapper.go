@@ -0,0 +1,67 @@
+package toolchaincluster
+
+import (
+	"context"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// SecretRefIndex the name of the index of the ToolchainClusters by the name of the secret referenced in their spec
+const SecretRefIndex = "spec.secretRef.name"
+
+// indexBySecretRef returns the name of the secret referenced by the given ToolchainCluster
+func indexBySecretRef(obj runtime.Object) []string {
+	toolchainCluster, ok := obj.(*toolchainv1alpha1.ToolchainCluster)
+	if !ok || toolchainCluster.Spec.SecretRef.Name == "" {
+		return nil
+	}
+	return []string{toolchainCluster.Spec.SecretRef.Name}
+}
+
+// MapSecretToToolchainClusters returns an event handler which converts events on a secret to requests on
+// the ToolchainClusters which reference this secret, so the cached client is rebuilt when the secret is rotated.
+// The ToolchainClusters are retrieved via the `SecretRefIndex` index.
+func MapSecretToToolchainClusters(cl client.Client, log logr.Logger) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &secretToToolchainClusterMapper{
+			client: cl,
+			log:    log,
+		},
+	}
+}
+
+var _ handler.Mapper = &secretToToolchainClusterMapper{}
+
+// secretToToolchainClusterMapper implementation of an handler mapper which
+// returns a reconcile request for each ToolchainCluster referencing the secret
+type secretToToolchainClusterMapper struct {
+	client client.Client
+	log    logr.Logger
+}
+
+// Map maps the secret to requests on the ToolchainClusters in the same namespace which reference it
+func (m secretToToolchainClusterMapper) Map(obj handler.MapObject) []reconcile.Request {
+	toolchainClusters := &toolchainv1alpha1.ToolchainClusterList{}
+	if err := m.client.List(context.TODO(), toolchainClusters,
+		client.InNamespace(obj.Meta.GetNamespace()),
+		client.MatchingFields{SecretRefIndex: obj.Meta.GetName()}); err != nil {
+		m.log.Error(err, "unable to list the ToolchainClusters referencing the secret", "secret", obj.Meta.GetName())
+		return []reconcile.Request{}
+	}
+	requests := make([]reconcile.Request, len(toolchainClusters.Items))
+	for i, toolchainCluster := range toolchainClusters.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: toolchainCluster.Namespace,
+				Name:      toolchainCluster.Name,
+			},
+		}
+	}
+	return requests
+}
@@ -0,0 +1,114 @@
+package toolchaincluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestIndexBySecretRef(t *testing.T) {
+	t.Run("ToolchainCluster with a secret", func(t *testing.T) {
+		// given
+		toolchainCluster, _ := test.NewToolchainCluster("east", "east-secret", toolchainv1alpha1.ToolchainClusterStatus{}, map[string]string{})
+
+		// when
+		values := indexBySecretRef(toolchainCluster)
+
+		// then
+		assert.Equal(t, []string{"east-secret"}, values)
+	})
+
+	t.Run("other resource", func(t *testing.T) {
+		// when
+		values := indexBySecretRef(&corev1.Secret{})
+
+		// then
+		assert.Empty(t, values)
+	})
+}
+
+func TestSecretMapper(t *testing.T) {
+	// given
+	east, eastSecret := test.NewToolchainCluster("east", "east-secret", toolchainv1alpha1.ToolchainClusterStatus{}, map[string]string{})
+	west, _ := test.NewToolchainCluster("west", "west-secret", toolchainv1alpha1.ToolchainClusterStatus{}, map[string]string{})
+	cl := test.NewFakeClient(t, east, west)
+	// the fake client doesn't support the field selectors, so the index is applied here
+	cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+		listOptions := &client.ListOptions{}
+		listOptions.ApplyOptions(opts)
+		secretName, found := listOptions.FieldSelector.RequiresExactMatch(SecretRefIndex)
+		require.True(t, found)
+		if err := cl.Client.List(ctx, list, opts...); err != nil {
+			return err
+		}
+		toolchainClusters := list.(*toolchainv1alpha1.ToolchainClusterList)
+		var items []toolchainv1alpha1.ToolchainCluster
+		for _, toolchainCluster := range toolchainClusters.Items {
+			if toolchainCluster.Spec.SecretRef.Name == secretName {
+				items = append(items, toolchainCluster)
+			}
+		}
+		toolchainClusters.Items = items
+		return nil
+	}
+
+	t.Run("secret referenced by a ToolchainCluster", func(t *testing.T) {
+		// when
+		result := secretToToolchainClusterMapper{client: cl, log: logf.Log}.Map(handler.MapObject{
+			Meta:   eastSecret,
+			Object: eastSecret,
+		})
+
+		// then
+		require.Len(t, result, 1)
+		assert.Equal(t, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: "test-namespace",
+				Name:      "east",
+			},
+		}, result[0])
+	})
+
+	t.Run("secret not referenced by any ToolchainCluster", func(t *testing.T) {
+		// given
+		_, otherSecret := test.NewToolchainCluster("other", "other-secret", toolchainv1alpha1.ToolchainClusterStatus{}, map[string]string{})
+
+		// when
+		result := secretToToolchainClusterMapper{client: cl, log: logf.Log}.Map(handler.MapObject{
+			Meta:   otherSecret,
+			Object: otherSecret,
+		})
+
+		// then
+		require.Empty(t, result)
+	})
+
+	t.Run("ToolchainClusters cannot be listed", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t, east)
+		cl.MockList = func(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+			return fmt.Errorf("mock error")
+		}
+
+		// when
+		result := secretToToolchainClusterMapper{client: cl, log: logf.Log}.Map(handler.MapObject{
+			Meta:   eastSecret,
+			Object: eastSecret,
+		})
+
+		// then
+		require.Empty(t, result)
+	})
+}
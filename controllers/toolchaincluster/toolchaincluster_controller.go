@@ -7,6 +7,7 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -30,7 +31,12 @@ func NewReconciler(mgr manager.Manager, log logr.Logger, namespace string, timeo
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, log logr.Logger) error {
+	// Index the ToolchainClusters by the name of their secret, so the secret events can be mapped to the ToolchainClusters
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &toolchainv1alpha1.ToolchainCluster{}, SecretRefIndex, indexBySecretRef); err != nil {
+		return err
+	}
+
 	// Create a new controller
 	c, err := controller.New("toolchaincluster-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -38,12 +44,17 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	}
 
 	// Watch for changes to primary resource ToolchainCluster
-	return c.Watch(&source.Kind{Type: &toolchainv1alpha1.ToolchainCluster{}}, &handler.EnqueueRequestForObject{})
+	if err := c.Watch(&source.Kind{Type: &toolchainv1alpha1.ToolchainCluster{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+
+	// Watch for changes to the secrets referenced by the ToolchainClusters, so a rotated token is taken into account right away
+	return c.Watch(&source.Kind{Type: &corev1.Secret{}}, MapSecretToToolchainClusters(mgr.GetClient(), log))
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
-	return add(mgr, r)
+	return add(mgr, r, r.log)
 }
 
 // Reconciler reconciles a ToolchainCluster object
@@ -0,0 +1,28 @@
+package concurrency
+
+import "sync"
+
+// DefaultMaxConcurrency the default maximum number of concurrent workers, eg: when applying objects or checking the health of the clusters
+const DefaultMaxConcurrency = 5
+
+// ForEach calls the given function for every index from `0` to `n-1`, with at most `maxConcurrency` concurrent workers,
+// and returns once all the calls are done. Each index is passed to a single call, so the function can write the result
+// at its index in a slice without any synchronization.
+func ForEach(n, maxConcurrency int, fn func(index int)) {
+	indexChan := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < maxConcurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexChan {
+				fn(index)
+			}
+		}()
+	}
+	for index := 0; index < n; index++ {
+		indexChan <- index
+	}
+	close(indexChan)
+	wg.Wait()
+}
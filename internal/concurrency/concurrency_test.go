@@ -0,0 +1,61 @@
+package concurrency
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEach(t *testing.T) {
+
+	t.Run("should call the function once for every index", func(t *testing.T) {
+		// given
+		calls := make([]int, 10)
+
+		// when
+		ForEach(len(calls), 3, func(index int) {
+			calls[index]++
+		})
+
+		// then
+		for index, count := range calls {
+			assert.Equal(t, 1, count, "unexpected number of calls for index %d", index)
+		}
+	})
+
+	t.Run("should not exceed the maximum concurrency", func(t *testing.T) {
+		// given
+		lock := sync.Mutex{}
+		running, maxRunning := 0, 0
+
+		// when
+		ForEach(10, 3, func(index int) {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			lock.Lock()
+			running--
+			lock.Unlock()
+		})
+
+		// then
+		assert.LessOrEqual(t, maxRunning, 3)
+	})
+
+	t.Run("should not call the function when there is no index", func(t *testing.T) {
+		// when
+		called := false
+		ForEach(0, 3, func(index int) {
+			called = true
+		})
+
+		// then
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"sort"
+
+	"github.com/codeready-toolchain/toolchain-common/internal/concurrency"
+
+	"github.com/pkg/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// kindOrder the order in which the kinds are applied by the batch applier: the kinds with the lowest value are applied first,
+// and all the objects with the same value are applied concurrently. The kinds which are not listed here use the `defaultKindOrder`.
+var kindOrder = map[string]int{
+	"Namespace":                0,
+	"CustomResourceDefinition": 0,
+	"ServiceAccount":           1,
+	"Role":                     1,
+	"ClusterRole":              1,
+	"ConfigMap":                1,
+	"Secret":                   1,
+	"LimitRange":               1,
+	"ResourceQuota":            1,
+	"ClusterResourceQuota":     1,
+	"NetworkPolicy":            1,
+	"PersistentVolumeClaim":    1,
+	"RoleBinding":              2,
+	"ClusterRoleBinding":       2,
+	"Deployment":               4,
+	"DeploymentConfig":         4,
+	"StatefulSet":              4,
+	"DaemonSet":                4,
+	"ReplicaSet":               4,
+	"ReplicationController":    4,
+	"Job":                      4,
+	"CronJob":                  4,
+	"Pod":                      4,
+}
+
+const defaultKindOrder = 3
+
+// ErrSkipped the error set in the result of the objects that were not applied because an object they may depend on could not be applied
+var ErrSkipped = errors.New("skipped because of a previous failure")
+
+// BatchApplyResult the result of applying a single object with the batch applier
+type BatchApplyResult struct {
+	Object ToolchainObject
+	// CreatedOrUpdated is `true` when the object was either created or updated
+	CreatedOrUpdated bool
+	// Err the error that occurred when applying the object (or `ErrSkipped`)
+	Err error
+}
+
+type batchApplyConfiguration struct {
+	maxConcurrency int
+	applyOptions   []ApplyObjectOption
+}
+
+func newBatchApplyConfiguration(options ...BatchApplyOption) batchApplyConfiguration {
+	config := batchApplyConfiguration{
+		maxConcurrency: concurrency.DefaultMaxConcurrency,
+		applyOptions:   []ApplyObjectOption{ForceUpdate(true)},
+	}
+	for _, apply := range options {
+		apply(&config)
+	}
+	return config
+}
+
+// BatchApplyOption an option when applying a batch of objects
+type BatchApplyOption func(*batchApplyConfiguration)
+
+// MaxConcurrency sets the maximum number of objects that are applied concurrently (default: `5`)
+func MaxConcurrency(maxConcurrency int) BatchApplyOption {
+	return func(config *batchApplyConfiguration) {
+		if maxConcurrency > 0 {
+			config.maxConcurrency = maxConcurrency
+		}
+	}
+}
+
+// WithApplyObjectOptions sets the options to use when applying each object (default: `ForceUpdate(true)`)
+func WithApplyObjectOptions(options ...ApplyObjectOption) BatchApplyOption {
+	return func(config *batchApplyConfiguration) {
+		config.applyOptions = options
+	}
+}
+
+// BatchApplyToolchainObjects applies the objects with the given labels, ordered by the dependencies of their kinds: the Namespaces
+// and CRDs first, then the ServiceAccounts, Roles (and alike), then the RoleBindings, and the workloads last.
+// The objects which don't depend on each other are applied concurrently. If any object fails to be applied, then the objects
+// that come after it in the order of dependencies are skipped.
+// If several objects have the same key (group, kind, namespace and name, regardless of the version), then the occurrences
+// after the first one fail and nothing is applied.
+// Returns the results (in the same order as the given objects) and an aggregated error of all the failures (or `nil`)
+func (p ApplyClient) BatchApplyToolchainObjects(toolchainObjects []ToolchainObject, newLabels map[string]string, options ...BatchApplyOption) ([]BatchApplyResult, error) {
+	return p.BatchApplyToolchainObjectsWithContext(context.TODO(), toolchainObjects, newLabels, options...)
+}
+
+// BatchApplyToolchainObjectsWithContext does the same as BatchApplyToolchainObjects, but all the calls to the API server use the given context
+func (p ApplyClient) BatchApplyToolchainObjectsWithContext(ctx context.Context, toolchainObjects []ToolchainObject, newLabels map[string]string, options ...BatchApplyOption) ([]BatchApplyResult, error) {
+	config := newBatchApplyConfiguration(options...)
+	results := make([]BatchApplyResult, len(toolchainObjects))
+	var failures []error
+	keys := make(map[ToolchainObjectKey]bool, len(toolchainObjects))
+	for i, toolchainObject := range toolchainObjects {
+		results[i].Object = toolchainObject
+		key := toolchainObject.GetKey()
+		if keys[key.WithoutVersion()] {
+			results[i].Err = errors.Errorf("the resource '%s' is duplicated", key)
+			failures = append(failures, results[i].Err)
+		}
+		keys[key.WithoutVersion()] = true
+	}
+	if len(failures) > 0 {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = ErrSkipped
+			}
+		}
+		return results, utilerrors.NewAggregate(failures)
+	}
+
+	for _, group := range groupByKindOrder(toolchainObjects) {
+		if len(failures) > 0 {
+			for _, index := range group {
+				results[index].Err = ErrSkipped
+			}
+			continue
+		}
+		p.applyConcurrently(ctx, toolchainObjects, group, newLabels, config, results)
+		for _, index := range group {
+			if results[index].Err != nil {
+				failures = append(failures, results[index].Err)
+			}
+		}
+	}
+	return results, utilerrors.NewAggregate(failures)
+}
+
+// applyConcurrently applies the objects at the given indexes with a bounded number of workers, and sets their results
+func (p ApplyClient) applyConcurrently(ctx context.Context, toolchainObjects []ToolchainObject, indexes []int, newLabels map[string]string, config batchApplyConfiguration, results []BatchApplyResult) {
+	concurrency.ForEach(len(indexes), config.maxConcurrency, func(i int) {
+		index := indexes[i]
+		toolchainObject := toolchainObjects[index]
+		addLabels(toolchainObject, newLabels)
+		gvk := toolchainObject.GetGvk()
+		createdOrUpdated, err := p.ApplyObjectWithContext(ctx, toolchainObject.GetRuntimeObject(), config.applyOptions...)
+		if err != nil {
+			err = errors.Wrapf(err, "unable to apply resource of kind: %s, version: %s, namespace: %s, name: %s",
+				gvk.Kind, gvk.Version, toolchainObject.GetNamespace(), toolchainObject.GetName())
+		}
+		results[index].CreatedOrUpdated = createdOrUpdated
+		results[index].Err = err
+	})
+}
+
+// groupByKindOrder returns the indexes of the given objects, grouped by the order of their kinds
+func groupByKindOrder(toolchainObjects []ToolchainObject) [][]int {
+	groups := map[int][]int{}
+	for i, toolchainObject := range toolchainObjects {
+		order, ok := kindOrder[toolchainObject.GetGvk().Kind]
+		if !ok {
+			order = defaultKindOrder
+		}
+		groups[order] = append(groups[order], i)
+	}
+	orders := make([]int, 0, len(groups))
+	for order := range groups {
+		orders = append(orders, order)
+	}
+	sort.Ints(orders)
+	sorted := make([][]int, 0, len(orders))
+	for _, order := range orders {
+		sorted = append(sorted, groups[order])
+	}
+	return sorted
+}
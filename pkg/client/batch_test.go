@@ -0,0 +1,240 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBatchApplyToolchainObjects(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	labels := newLabels("basic", "john", "dev")
+
+	newObjects := func(t *testing.T) []client.ToolchainObject {
+		objs := []runtime.Object{
+			&appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "john-dev"},
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: "john-edit", Namespace: "john-dev"},
+			},
+			&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "john-dev"},
+			},
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: "edit", Namespace: "john-dev"},
+			},
+			&corev1.Namespace{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+				ObjectMeta: metav1.ObjectMeta{Name: "john-dev"},
+			},
+		}
+		toolchainObjects := make([]client.ToolchainObject, len(objs))
+		for i, obj := range objs {
+			toolchainObject, err := client.NewToolchainObject(obj)
+			require.NoError(t, err)
+			toolchainObjects[i] = toolchainObject
+		}
+		return toolchainObjects
+	}
+
+	recordCreatedKinds := func(cl *FakeClient) *[]string {
+		lock := sync.Mutex{}
+		var kinds []string
+		cl.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+			lock.Lock()
+			kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+			lock.Unlock()
+			return Create(ctx, cl, obj, opts...)
+		}
+		return &kinds
+	}
+
+	t.Run("should apply all objects in the order of dependencies", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t)
+		createdKinds := recordCreatedKinds(cl)
+
+		// when
+		results, err := client.NewApplyClient(cl, s).BatchApplyToolchainObjects(newObjects(t), labels, client.MaxConcurrency(1))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Namespace", "ConfigMap", "Role", "RoleBinding", "Deployment"}, *createdKinds)
+		require.Len(t, results, 5)
+		for _, result := range results {
+			assert.True(t, result.CreatedOrUpdated)
+			assert.NoError(t, result.Err)
+			assert.Equal(t, "basic", result.Object.GetLabels()["toolchain.dev.openshift.com/tier"])
+		}
+		// results are in the same order as the given objects
+		assert.Equal(t, "Deployment", results[0].Object.GetGvk().Kind)
+		assert.Equal(t, "Namespace", results[4].Object.GetGvk().Kind)
+
+		t.Run("nothing changed when applied again", func(t *testing.T) {
+			// when
+			results, err := client.NewApplyClient(cl, s).BatchApplyToolchainObjects(newObjects(t), labels)
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 5)
+			for _, result := range results {
+				assert.False(t, result.CreatedOrUpdated)
+				assert.NoError(t, result.Err)
+			}
+		})
+	})
+
+	t.Run("should not apply more objects concurrently than the max concurrency", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t)
+		lock := sync.Mutex{}
+		current, maxCurrent := 0, 0
+		cl.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+			lock.Lock()
+			current++
+			if current > maxCurrent {
+				maxCurrent = current
+			}
+			lock.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			lock.Lock()
+			current--
+			lock.Unlock()
+			return Create(ctx, cl, obj, opts...)
+		}
+		objs := make([]client.ToolchainObject, 10)
+		for i := range objs {
+			obj, err := client.NewToolchainObject(&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("config-%d", i), Namespace: "john-dev"},
+			})
+			require.NoError(t, err)
+			objs[i] = obj
+		}
+
+		// when
+		results, err := client.NewApplyClient(cl, s).BatchApplyToolchainObjects(objs, labels, client.MaxConcurrency(3))
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 10)
+		assert.LessOrEqual(t, maxCurrent, 3)
+		assert.Greater(t, maxCurrent, 1)
+	})
+
+	t.Run("should return all failures and skip the dependent objects", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t)
+		cl.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+			kind := obj.GetObjectKind().GroupVersionKind().Kind
+			if kind == "ConfigMap" || kind == "Role" {
+				return fmt.Errorf("unable to create %s", kind)
+			}
+			return Create(ctx, cl, obj, opts...)
+		}
+
+		// when
+		results, err := client.NewApplyClient(cl, s).BatchApplyToolchainObjects(newObjects(t), labels)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to apply resource of kind: ConfigMap, version: v1, namespace: john-dev, name: config")
+		assert.Contains(t, err.Error(), "unable to create ConfigMap")
+		assert.Contains(t, err.Error(), "unable to apply resource of kind: Role, version: v1, namespace: john-dev, name: edit")
+		assert.Contains(t, err.Error(), "unable to create Role")
+		assert.NotContains(t, err.Error(), client.ErrSkipped.Error())
+		require.Len(t, results, 5)
+		assert.Equal(t, client.ErrSkipped, results[0].Err) // Deployment
+		assert.Equal(t, client.ErrSkipped, results[1].Err) // RoleBinding
+		assert.Error(t, results[2].Err)                    // ConfigMap
+		assert.Error(t, results[3].Err)                    // Role
+		assert.NoError(t, results[4].Err)                  // Namespace
+		assert.True(t, results[4].CreatedOrUpdated)
+	})
+
+	t.Run("should use the given context", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t)
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		cl.MockGet = func(ctx context.Context, key runtimeclient.ObjectKey, obj runtime.Object) error {
+			return ctx.Err()
+		}
+
+		// when
+		results, err := client.NewApplyClient(cl, s).BatchApplyToolchainObjectsWithContext(ctx, newObjects(t), labels)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "context canceled")
+		require.Len(t, results, 5)
+		assert.Equal(t, client.ErrSkipped, results[0].Err) // Deployment
+		assert.Error(t, results[4].Err)                    // Namespace
+		assert.NotEqual(t, client.ErrSkipped, results[4].Err)
+	})
+
+	t.Run("objects with the same name in different namespaces", func(t *testing.T) {
+		// given
+		newRoleBinding := func(namespace string) client.ToolchainObject {
+			obj, err := client.NewToolchainObject(&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: "john-edit", Namespace: namespace},
+			})
+			require.NoError(t, err)
+			return obj
+		}
+
+		t.Run("should apply all of them", func(t *testing.T) {
+			// given
+			cl := NewFakeClient(t)
+
+			// when
+			results, err := client.NewApplyClient(cl, s).BatchApplyToolchainObjects([]client.ToolchainObject{newRoleBinding("john-dev"), newRoleBinding("john-stage")}, labels)
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.True(t, results[0].CreatedOrUpdated)
+			assert.True(t, results[1].CreatedOrUpdated)
+		})
+
+		t.Run("should not apply anything when an object is duplicated", func(t *testing.T) {
+			// given
+			cl := NewFakeClient(t)
+			objs := []client.ToolchainObject{newRoleBinding("john-dev"), newRoleBinding("john-stage"), newRoleBinding("john-dev")}
+
+			// when
+			results, err := client.NewApplyClient(cl, s).BatchApplyToolchainObjects(objs, labels)
+
+			// then
+			require.EqualError(t, err, "the resource 'rbac.authorization.k8s.io/v1/RoleBinding/john-dev/john-edit' is duplicated")
+			require.Len(t, results, 3)
+			assert.Equal(t, client.ErrSkipped, results[0].Err)
+			assert.Equal(t, client.ErrSkipped, results[1].Err)
+			assert.EqualError(t, results[2].Err, "the resource 'rbac.authorization.k8s.io/v1/RoleBinding/john-dev/john-edit' is duplicated")
+			for _, result := range results {
+				assert.False(t, result.CreatedOrUpdated)
+			}
+		})
+	})
+}
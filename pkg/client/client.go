@@ -5,22 +5,27 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
-	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-// LastAppliedConfigurationAnnotationKey the key to save the last applied configuration in the resource annotations
-const LastAppliedConfigurationAnnotationKey = "toolchain.dev.openshift.com/last-applied-configuration"
+const (
+	// LastAppliedConfigurationAnnotationKey the key to save the last applied configuration in the resource annotations
+	LastAppliedConfigurationAnnotationKey = "toolchain.dev.openshift.com/last-applied-configuration"
+
+	// DefaultFieldManager the name of the field manager used with the server-side apply when none was specified
+	DefaultFieldManager = "toolchain-operator"
+)
 
 var log = logf.Log.WithName("apply_client")
 
@@ -36,9 +41,19 @@ func NewApplyClient(cl client.Client, scheme *runtime.Scheme) *ApplyClient {
 }
 
 type applyObjectConfiguration struct {
-	owner             v1.Object
-	forceUpdate       bool
-	saveConfiguration bool
+	owner                 v1.Object
+	controllerOwner       bool
+	crossClusterOwner     v1.Object
+	ownerClusterName      string
+	updateOwnerReferences bool
+	forceUpdate           bool
+	saveConfiguration     bool
+	storageMode           ConfigurationStorageMode
+	serverSideApply       bool
+	fieldManager          string
+	forceConflicts        bool
+	mergePatch            bool
+	eventRecorder         record.EventRecorder
 }
 
 func newApplyObjectConfiguration(options ...ApplyObjectOption) applyObjectConfiguration {
@@ -46,6 +61,7 @@ func newApplyObjectConfiguration(options ...ApplyObjectOption) applyObjectConfig
 		owner:             nil,
 		forceUpdate:       false,
 		saveConfiguration: true,
+		storageMode:       StoreConfigurationAsJSON,
 	}
 	for _, apply := range options {
 		apply(&config)
@@ -56,10 +72,38 @@ func newApplyObjectConfiguration(options ...ApplyObjectOption) applyObjectConfig
 // ApplyObjectOption an option when creating or updating a resource
 type ApplyObjectOption func(*applyObjectConfiguration)
 
-// SetOwner sets the owner of the resource (default: `nil`)
+// SetOwner sets the owner of the resource as a controller reference (default: `nil`)
 func SetOwner(owner v1.Object) ApplyObjectOption {
 	return func(config *applyObjectConfiguration) {
 		config.owner = owner
+		config.controllerOwner = true
+	}
+}
+
+// SetNonControllerOwner sets the owner of the resource as a regular owner reference, ie, not as a controller reference (default: `nil`).
+// This way, the resource can have several owners.
+func SetNonControllerOwner(owner v1.Object) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.owner = owner
+		config.controllerOwner = false
+	}
+}
+
+// SetCrossClusterOwner sets the owner of the resource with labels and annotations (see `OwnerNameLabelKey` and the other keys),
+// since the owner references cannot refer to an owner in another namespace or in another cluster.
+// The cluster name is empty when the owner is in the same cluster (default: `nil`)
+func SetCrossClusterOwner(owner v1.Object, clusterName string) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.crossClusterOwner = owner
+		config.ownerClusterName = clusterName
+	}
+}
+
+// UpdateOwnerReferences sets the owner reference (see `SetOwner` and `SetNonControllerOwner`) when updating an existing resource too,
+// while retaining its other owner references (default: `false`, ie, the owner reference is only set when creating the resource)
+func UpdateOwnerReferences(updateOwnerReferences bool) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.updateOwnerReferences = updateOwnerReferences
 	}
 }
 
@@ -78,134 +122,237 @@ func SaveConfiguration(saveConfiguration bool) ApplyObjectOption {
 	}
 }
 
+// StoreConfiguration sets how the applied configuration is saved
+// in the resource annotations (default: `StoreConfigurationAsJSON`)
+func StoreConfiguration(mode ConfigurationStorageMode) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.storageMode = mode
+	}
+}
+
+// ServerSideApply uses the Kubernetes server-side apply to create or update the resource,
+// with the given field manager (default: `DefaultFieldManager`). When enabled, the last applied
+// configuration is not saved in the resource annotations, since the server tracks the managed fields.
+func ServerSideApply(fieldManager string) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.serverSideApply = true
+		config.fieldManager = fieldManager
+	}
+}
+
+// ForceConflicts forces the server-side apply to take the ownership of the fields
+// that are managed by another field manager (default: `false`)
+func ForceConflicts(forceConflicts bool) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.forceConflicts = forceConflicts
+	}
+}
+
+// ThreeWayMergePatch updates the resource with a patch computed from the last applied configuration,
+// the desired object and the existing object, instead of replacing the whole resource (default: `false`).
+// This way, the fields set by other actors are retained. A strategic merge patch is used for Kubernetes built-in types,
+// and a JSON merge patch for the other types (eg: CRDs)
+func ThreeWayMergePatch(mergePatch bool) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.mergePatch = mergePatch
+	}
+}
+
+// RecordEvents records an event on the owner of the resource (see `SetOwner` and `SetNonControllerOwner`) with the given recorder
+// when the resource is created, updated or when it could not be applied (default: `nil`, ie, no event is recorded)
+func RecordEvents(recorder record.EventRecorder) ApplyObjectOption {
+	return func(config *applyObjectConfiguration) {
+		config.eventRecorder = recorder
+	}
+}
+
 // ApplyObject creates the object if is missing and if the owner object is provided, then it's set as a controller reference.
 // If the objects exists then when the spec content has changed (based on the content of the annotation in the original object) then it
 // is automatically updated. If it looks to be same then based on the value of forceUpdate param it updates the object or not.
-// The return boolean says if the object was either created or updated (`true`). If nothing changed (ie, the generation was not
-// incremented by the server), then it returns `false`.
+// The return boolean says if the object was either created or updated (`true`). If nothing changed, then it returns `false`.
+// See `ApplyObjectWithResult` to distinguish the creation from the update.
 func (p ApplyClient) ApplyObject(obj runtime.Object, options ...ApplyObjectOption) (bool, error) {
+	return p.ApplyObjectWithContext(context.TODO(), obj, options...)
+}
+
+// ApplyObjectWithContext does the same as ApplyObject, but all the calls to the API server use the given context,
+// so they are canceled when the context is canceled or when its deadline is exceeded.
+// The logger carried by the context (see ContextWithLogger) is used instead of the default one.
+func (p ApplyClient) ApplyObjectWithContext(ctx context.Context, obj runtime.Object, options ...ApplyObjectOption) (bool, error) {
+	result, err := p.ApplyObjectWithResult(ctx, obj, options...)
+	return result.Action == ApplyActionCreate || result.Action == ApplyActionUpdate, err
+}
+
+// ApplyObjectWithResult does the same as ApplyObjectWithContext, but returns whether the object was created, updated or left unchanged,
+// along with its resourceVersion before and after it was applied.
+// If an event recorder was provided with the `RecordEvents` option, then an event is recorded on the owner of the object (if any).
+func (p ApplyClient) ApplyObjectWithResult(ctx context.Context, obj runtime.Object, options ...ApplyObjectOption) (ApplyResult, error) {
 	gvk := obj.GetObjectKind().GroupVersionKind()
-	createdOrUpdated, err := p.applyObject(obj, options...)
+	config := newApplyObjectConfiguration(options...)
+	result, err := p.applyObject(ctx, obj, config)
 	if err != nil {
-		return createdOrUpdated, errors.Wrapf(err, "unable to create resource of kind: %s, version: %s", gvk.Kind, gvk.Version)
+		result.Action = ApplyActionError
 	}
-	return createdOrUpdated, nil
+	p.recordEvent(config, obj, result, err)
+	if err != nil {
+		return result, errors.Wrapf(err, "unable to create resource of kind: %s, version: %s", gvk.Kind, gvk.Version)
+	}
+	return result, nil
 }
 
-func (p ApplyClient) applyObject(obj runtime.Object, options ...ApplyObjectOption) (bool, error) {
-	// gets the meta accessor to the new resource
+func (p ApplyClient) applyObject(ctx context.Context, obj runtime.Object, config applyObjectConfiguration) (ApplyResult, error) {
+	logger := loggerFromContext(ctx)
 	// gets the meta accessor to the new resource
 	metaNew, err := meta.Accessor(obj)
 	if err != nil {
-		return false, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+		return ApplyResult{}, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+	}
+	if config.crossClusterOwner != nil {
+		if err := p.setCrossClusterOwner(metaNew, config.crossClusterOwner, config.ownerClusterName); err != nil {
+			return ApplyResult{}, err
+		}
+	}
+	if config.serverSideApply {
+		return p.serverSideApplyObj(ctx, obj, metaNew, config)
 	}
-	config := newApplyObjectConfiguration(options...)
 
-	// creates a deepcopy of the new resource to be used to check if it already exists
-	existing := obj.DeepCopyObject()
+	// creates a new instance of the resource to be used to check if it already exists
+	existing := p.newExistingObject(obj)
 
 	var newConfiguration string
 	if config.saveConfiguration {
 		// set current object as annotation
 		annotations := metaNew.GetAnnotations()
-		newConfiguration = getNewConfiguration(obj)
+		newConfiguration = getNewConfiguration(logger, obj)
 		if annotations == nil {
 			annotations = map[string]string{}
 		}
-		annotations[LastAppliedConfigurationAnnotationKey] = newConfiguration
+		encodedConfiguration, err := encodeConfiguration(newConfiguration, config.storageMode)
+		if err != nil {
+			return ApplyResult{}, errors.Wrapf(err, "unable to encode the configuration of %+v", obj)
+		}
+		annotations[LastAppliedConfigurationAnnotationKey] = encodedConfiguration
 		metaNew.SetAnnotations(annotations)
 	}
 	// gets current object (if exists)
 	namespacedName := types.NamespacedName{Namespace: metaNew.GetNamespace(), Name: metaNew.GetName()}
-	if err := p.cl.Get(context.TODO(), namespacedName, existing); err != nil {
+	if err := p.cl.Get(ctx, namespacedName, existing); err != nil {
 		if apierrors.IsNotFound(err) {
-			return true, p.createObj(obj, metaNew, config.owner)
+			if err := p.createObj(ctx, obj, metaNew, config); err != nil {
+				return ApplyResult{}, err
+			}
+			return ApplyResult{Action: ApplyActionCreate, NewResourceVersion: metaNew.GetResourceVersion()}, nil
 		}
-		return false, errors.Wrapf(err, "unable to get the resource '%v'", existing)
+		return ApplyResult{}, errors.Wrapf(err, "unable to get the resource '%v'", existing)
 	}
 
 	// gets the meta accessor to the existing resource
 	metaExisting, err := meta.Accessor(existing)
 	if err != nil {
-		return false, errors.Wrapf(err, "cannot get metadata from %+v", existing)
+		return ApplyResult{}, errors.Wrapf(err, "cannot get metadata from %+v", existing)
+	}
+	unchanged := ApplyResult{
+		Action:             ApplyActionUnchanged,
+		OldResourceVersion: metaExisting.GetResourceVersion(),
+		NewResourceVersion: metaExisting.GetResourceVersion(),
+	}
+
+	ownerReferencesChanged := false
+	if config.updateOwnerReferences {
+		if ownerReferencesChanged, err = p.fixOwnerReferences(metaNew, metaExisting, config); err != nil {
+			return unchanged, err
+		}
 	}
 
 	// as it already exists, check using the UpdateStrategy if it should be updated
-	if !config.forceUpdate {
-		existingAnnotations := metaExisting.GetAnnotations()
+	existingAnnotations := metaExisting.GetAnnotations()
+	if !config.forceUpdate && !ownerReferencesChanged {
 		if existingAnnotations != nil {
-			if newConfiguration == existingAnnotations[LastAppliedConfigurationAnnotationKey] {
-				return false, nil
+			if sameConfiguration(logger, existingAnnotations[LastAppliedConfigurationAnnotationKey], newConfiguration) {
+				return unchanged, nil
 			}
 		}
 	}
 
+	// the three-way merge patch needs the last applied configuration to remove the fields which are not desired anymore,
+	// so if only its hash was stored, the resource is updated instead, to not leave these fields behind
+	if config.mergePatch && !isHashedConfiguration(existingAnnotations[LastAppliedConfigurationAnnotationKey]) {
+		return p.patchObj(ctx, obj, existing, existingAnnotations[LastAppliedConfigurationAnnotationKey])
+	}
+
 	// retrieve the current 'resourceVersion' to set it in the resource passed to the `client.Update()`
 	// otherwise we would get an error with the following message:
 	// `nstemplatetiers.toolchain.dev.openshift.com "basic" is invalid: metadata.resourceVersion: Invalid value: 0x0: must be specified for an update`
-	originalGeneration := metaExisting.GetGeneration()
 	metaNew.SetResourceVersion(metaExisting.GetResourceVersion())
 
-	// also, if the resource to create is a Service and there's a previous version, we should retain its `spec.ClusterIP`, otherwise
+	// also, if there's a previous version, we should retain its immutable fields (eg: `spec.ClusterIP` of a Service), otherwise
 	// the update will fail with the following error:
 	// `Service "<name>" is invalid: spec.clusterIP: Invalid value: "": field is immutable`
-	if err := RetainClusterIP(obj, existing); err != nil {
-		return false, err
+	if err := RetainFields(p.scheme, obj, existing); err != nil {
+		return unchanged, err
 	}
-	if err := p.cl.Update(context.TODO(), obj); err != nil {
-		return false, errors.Wrapf(err, "unable to update the resource '%v'", obj)
+	if err := p.cl.Update(ctx, obj); err != nil {
+		return unchanged, errors.Wrapf(err, "unable to update the resource '%v'", obj)
 	}
 
-	// gets the meta accessor to the resource that was updated
-	metaNewAfterUpdate, err := meta.Accessor(obj)
+	// check if it was changed or not
+	return newUpdateResult(existing, obj)
+}
+
+// newExistingObject returns a new instance of the type of the given object, to retrieve the existing object in it.
+// This way, none of the fields of the given object are kept in the existing object (eg: the entries of a map).
+// Returns a deep copy of the given object if its type is not registered in the scheme.
+func (p ApplyClient) newExistingObject(obj runtime.Object) runtime.Object {
+	gvk, err := apiutil.GVKForObject(obj, p.scheme)
 	if err != nil {
-		return false, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+		return obj.DeepCopyObject()
 	}
-
-	// check if it was changed or not
-	return originalGeneration != metaNewAfterUpdate.GetGeneration(), nil
+	existing, err := newObject(p.scheme, obj, gvk)
+	if err != nil {
+		return obj.DeepCopyObject()
+	}
+	return existing
 }
 
 // RetainClusterIP sets the `spec.clusterIP` value from the given 'existing' object
 // into the 'newResource' object.
+//
+// Deprecated: use RetainFields instead, which applies all the rules registered for the kind of the object
 func RetainClusterIP(newResource, existing runtime.Object) error {
-	clusterIP, found, err := clusterIP(existing)
+	return RetainNestedFields([]string{"spec", "clusterIP"})(newResource, existing)
+}
+
+// patchObj updates the existing object with a three-way merge patch
+func (p ApplyClient) patchObj(ctx context.Context, obj, existing runtime.Object, lastAppliedConfiguration string) (ApplyResult, error) {
+	gvk, err := apiutil.GVKForObject(obj, p.scheme)
 	if err != nil {
-		return err
+		return ApplyResult{}, errors.Wrapf(err, "unable to get the GVK of the resource '%v'", obj)
 	}
-	if !found {
-		// skip
-		return nil
+	// retain the immutable fields (eg: `spec.ClusterIP` of a Service), so the patch does not try to change them
+	if err := RetainFields(p.scheme, obj, existing); err != nil {
+		return ApplyResult{}, err
 	}
-	switch newResource := newResource.(type) {
-	case *corev1.Service:
-		newResource.Spec.ClusterIP = clusterIP
-	case *unstructured.Unstructured:
-		if err := unstructured.SetNestedField(newResource.Object, clusterIP, "spec", "clusterIP"); err != nil {
-			return err
-		}
-	default:
-		// do nothing, object is not a service
+	original, err := decodeConfiguration(lastAppliedConfiguration)
+	if err != nil {
+		return ApplyResult{}, errors.Wrapf(err, "unable to read the last applied configuration of the resource '%v'", existing)
 	}
-	return nil
-}
-
-func clusterIP(obj runtime.Object) (string, bool, error) {
-	switch obj := obj.(type) {
-	case *corev1.Service:
-		return obj.Spec.ClusterIP, obj.Spec.ClusterIP != "", nil
-	case *unstructured.Unstructured:
-		return unstructured.NestedString(obj.Object, "spec", "clusterIP")
-	default:
-		// do nothing, object is not a service
-		return "", false, nil
+	patchType, patch, changed, err := createThreeWayMergePatch(gvk, original, obj, existing)
+	if err != nil {
+		return ApplyResult{}, errors.Wrapf(err, "unable to create the patch for the resource '%v'", obj)
+	}
+	if !changed {
+		return newUpdateResult(existing, existing)
 	}
+	if err := p.cl.Patch(ctx, obj, client.RawPatch(patchType, patch)); err != nil {
+		return ApplyResult{}, errors.Wrapf(err, "unable to patch the resource '%v'", obj)
+	}
+	return newUpdateResult(existing, obj)
 }
 
-func getNewConfiguration(newResource runtime.Object) string {
+func getNewConfiguration(logger logr.Logger, newResource runtime.Object) string {
 	newJSON, err := marshalObjectContent(newResource)
 	if err != nil {
-		log.Error(err, "unable to marshal the object", "object", newResource)
+		logger.Error(err, "unable to marshal the object", "object", newResource)
 		return fmt.Sprintf("%v", newResource)
 	}
 	return string(newJSON)
@@ -218,34 +365,93 @@ func marshalObjectContent(newResource runtime.Object) ([]byte, error) {
 	return json.Marshal(newResource)
 }
 
-func (p ApplyClient) createObj(newResource runtime.Object, metaNew v1.Object, owner v1.Object) error {
-	if owner != nil {
-		err := controllerutil.SetControllerReference(owner, metaNew, p.scheme)
+func (p ApplyClient) createObj(ctx context.Context, newResource runtime.Object, metaNew v1.Object, config applyObjectConfiguration) error {
+	if err := p.setOwnerReference(metaNew, config); err != nil {
+		return err
+	}
+	return p.cl.Create(ctx, newResource)
+}
+
+// serverSideApplyObj creates or updates the object using the server-side apply. The result says if the object
+// was either created or updated, based on the `resourceVersion` of the existing object (if any) and the one returned by the server.
+func (p ApplyClient) serverSideApplyObj(ctx context.Context, obj runtime.Object, metaNew v1.Object, config applyObjectConfiguration) (ApplyResult, error) {
+	// the server-side apply requires the apiVersion and kind to be set in the payload
+	gvk, err := apiutil.GVKForObject(obj, p.scheme)
+	if err != nil {
+		return ApplyResult{}, errors.Wrapf(err, "unable to get the GVK of the resource '%v'", obj)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	// gets current resourceVersion (if exists)
+	existing := obj.DeepCopyObject()
+	namespacedName := types.NamespacedName{Namespace: metaNew.GetNamespace(), Name: metaNew.GetName()}
+	originalResourceVersion := ""
+	if err := p.cl.Get(ctx, namespacedName, existing); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ApplyResult{}, errors.Wrapf(err, "unable to get the resource '%v'", existing)
+		}
+	} else {
+		metaExisting, err := meta.Accessor(existing)
 		if err != nil {
-			return errors.Wrap(err, "unable to set controller references")
+			return ApplyResult{}, errors.Wrapf(err, "cannot get metadata from %+v", existing)
 		}
+		originalResourceVersion = metaExisting.GetResourceVersion()
+	}
+
+	if err := p.setOwnerReference(metaNew, config); err != nil {
+		return ApplyResult{}, err
+	}
+	// the managed fields and resourceVersion must not be sent as part of the applied configuration
+	metaNew.SetManagedFields(nil)
+	metaNew.SetResourceVersion("")
+
+	fieldManager := config.fieldManager
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	patchOptions := []client.PatchOption{client.FieldOwner(fieldManager)}
+	if config.forceConflicts {
+		patchOptions = append(patchOptions, client.ForceOwnership)
+	}
+	if err := p.cl.Patch(ctx, obj, client.Apply, patchOptions...); err != nil {
+		return ApplyResult{}, errors.Wrapf(err, "unable to apply the resource '%v'", obj)
+	}
+
+	// check if it was created or changed
+	result := ApplyResult{
+		Action:             ApplyActionUpdate,
+		OldResourceVersion: originalResourceVersion,
+		NewResourceVersion: metaNew.GetResourceVersion(),
 	}
-	return p.cl.Create(context.TODO(), newResource)
+	switch {
+	case originalResourceVersion == "":
+		result.Action = ApplyActionCreate
+	case originalResourceVersion == metaNew.GetResourceVersion():
+		result.Action = ApplyActionUnchanged
+	}
+	return result, nil
 }
 
 // ApplyToolchainObjects applies the objects, ie, creates or updates them on the cluster
 // returns `true, nil` if at least one of the objects was created or modified,
 // `false, nil` if nothing changed, and `false, err` if an error occurred
 func (p ApplyClient) ApplyToolchainObjects(toolchainObjects []ToolchainObject, newLabels map[string]string) (bool, error) {
+	return p.ApplyToolchainObjectsWithContext(context.TODO(), toolchainObjects, newLabels)
+}
+
+// ApplyToolchainObjectsWithContext does the same as ApplyToolchainObjects, but all the calls to the API server use the given context.
+// The remaining objects are not applied once the context is canceled or its deadline is exceeded.
+func (p ApplyClient) ApplyToolchainObjectsWithContext(ctx context.Context, toolchainObjects []ToolchainObject, newLabels map[string]string) (bool, error) {
 	createdOrUpdated := false
 	for _, toolchainObject := range toolchainObjects {
-		// set newLabels
-		labels := toolchainObject.GetLabels()
-		if labels == nil {
-			labels = make(map[string]string)
-		}
-		for key, value := range newLabels {
-			labels[key] = value
+		gvk := toolchainObject.GetGvk()
+		if err := ctx.Err(); err != nil {
+			return false, errors.Wrapf(err, "unable to create resource of kind: %s, version: %s", gvk.Kind, gvk.Version)
 		}
-		toolchainObject.SetLabels(labels)
+		// set newLabels
+		addLabels(toolchainObject, newLabels)
 
-		gvk := toolchainObject.GetGvk()
-		result, err := p.ApplyObject(toolchainObject.GetRuntimeObject(), ForceUpdate(true))
+		result, err := p.ApplyObjectWithContext(ctx, toolchainObject.GetRuntimeObject(), ForceUpdate(true))
 		if err != nil {
 			return false, errors.Wrapf(err, "unable to create resource of kind: %s, version: %s", gvk.Kind, gvk.Version)
 		}
@@ -253,3 +459,15 @@ func (p ApplyClient) ApplyToolchainObjects(toolchainObjects []ToolchainObject, n
 	}
 	return createdOrUpdated, nil
 }
+
+// addLabels sets the given labels on the object, while keeping the other existing labels
+func addLabels(obj v1.Object, newLabels map[string]string) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	for key, value := range newLabels {
+		labels[key] = value
+	}
+	obj.SetLabels(labels)
+}
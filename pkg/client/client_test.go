@@ -317,6 +317,237 @@ func TestApplySingle(t *testing.T) {
 		})
 	})
 
+	t.Run("with server-side apply", func(t *testing.T) {
+
+		mockApplyPatch := func(t *testing.T, cli *test.FakeClient, resourceVersion string, verify func(opts *runtimeclient.PatchOptions)) {
+			cli.MockPatch = func(ctx context.Context, obj runtime.Object, patch runtimeclient.Patch, opts ...runtimeclient.PatchOption) error {
+				require.Equal(t, types.ApplyPatchType, patch.Type())
+				data, err := patch.Data(obj)
+				require.NoError(t, err)
+				assert.Contains(t, string(data), `"kind":"Service"`)
+				assert.NotContains(t, string(data), client.LastAppliedConfigurationAnnotationKey)
+				patchOptions := &runtimeclient.PatchOptions{}
+				patchOptions.ApplyOptions(opts)
+				verify(patchOptions)
+				// simulate the response of the server
+				objMeta, err := meta.Accessor(obj)
+				require.NoError(t, err)
+				objMeta.SetResourceVersion(resourceVersion)
+				return nil
+			}
+		}
+
+		t.Run("when object is missing, it should create it with the default field manager", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			mockApplyPatch(t, cli, "1", func(opts *runtimeclient.PatchOptions) {
+				assert.Equal(t, client.DefaultFieldManager, opts.FieldManager)
+				assert.Nil(t, opts.Force)
+			})
+			obj := modifiedService.DeepCopy()
+			obj.TypeMeta = metav1.TypeMeta{}
+
+			// when
+			createdOrChanged, err := cl.ApplyObject(obj, client.ServerSideApply(""), client.SetOwner(&appsv1.Deployment{}))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, createdOrChanged)
+			assert.NotEmpty(t, obj.OwnerReferences)
+		})
+
+		t.Run("it should update with the given field manager and force conflicts", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			_, err := cl.ApplyObject(defaultService.DeepCopyObject())
+			require.NoError(t, err)
+			mockApplyPatch(t, cli, "2", func(opts *runtimeclient.PatchOptions) {
+				assert.Equal(t, "member-operator", opts.FieldManager)
+				require.NotNil(t, opts.Force)
+				assert.True(t, *opts.Force)
+			})
+
+			// when
+			createdOrChanged, err := cl.ApplyObject(modifiedService.DeepCopyObject(), client.ServerSideApply("member-operator"), client.ForceConflicts(true))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, createdOrChanged)
+		})
+
+		t.Run("it should not report an update when the resourceVersion did not change", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			_, err := cl.ApplyObject(defaultService.DeepCopyObject())
+			require.NoError(t, err)
+			service := &corev1.Service{}
+			err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}, service)
+			require.NoError(t, err)
+			mockApplyPatch(t, cli, service.ResourceVersion, func(opts *runtimeclient.PatchOptions) {})
+
+			// when
+			createdOrChanged, err := cl.ApplyObject(defaultService.DeepCopyObject(), client.ServerSideApply(""))
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, createdOrChanged)
+		})
+
+		t.Run("when patch fails, then it should return an error", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			cli.MockPatch = func(ctx context.Context, obj runtime.Object, patch runtimeclient.Patch, opts ...runtimeclient.PatchOption) error {
+				return fmt.Errorf("conflict")
+			}
+
+			// when
+			createdOrChanged, err := cl.ApplyObject(defaultService.DeepCopyObject(), client.ServerSideApply(""))
+
+			// then
+			require.Error(t, err)
+			assert.False(t, createdOrChanged)
+			assert.Contains(t, err.Error(), "unable to apply the resource")
+		})
+	})
+
+	t.Run("with three-way merge patch", func(t *testing.T) {
+
+		namespacedName := types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}
+
+		recordPatchType := func(cli *test.FakeClient) *types.PatchType {
+			var patchType types.PatchType
+			cli.MockPatch = func(ctx context.Context, obj runtime.Object, patch runtimeclient.Patch, opts ...runtimeclient.PatchOption) error {
+				patchType = patch.Type()
+				return cli.Client.Patch(ctx, obj, patch, opts...)
+			}
+			return &patchType
+		}
+
+		t.Run("it should patch Service and retain fields set by other actors", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			_, err := cl.ApplyObject(defaultService.DeepCopyObject())
+			require.NoError(t, err)
+			// another actor sets a label on the existing object
+			service := &corev1.Service{}
+			err = cli.Get(context.TODO(), namespacedName, service)
+			require.NoError(t, err)
+			service.Labels = map[string]string{"set-by": "someone-else"}
+			err = cli.Update(context.TODO(), service)
+			require.NoError(t, err)
+			patchType := recordPatchType(cli)
+
+			// when
+			obj := modifiedService.DeepCopy()
+			obj.Spec.ClusterIP = ""
+			createdOrChanged, err := cl.ApplyObject(obj, client.ThreeWayMergePatch(true))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, createdOrChanged)
+			assert.Equal(t, types.StrategicMergePatchType, *patchType)
+			err = cli.Get(context.TODO(), namespacedName, service)
+			require.NoError(t, err)
+			assert.Equal(t, "all-services", service.Spec.Selector["run"])
+			assert.Equal(t, "10.2.3.4", service.Spec.ClusterIP)
+			assert.Equal(t, "someone-else", service.Labels["set-by"])
+			assert.Equal(t, service.ResourceVersion, obj.ResourceVersion)
+		})
+
+		t.Run("it should remove fields that are not in the desired object anymore", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			cm := defaultCm.DeepCopy()
+			cm.Data["second-param"] = "second-value"
+			_, err := cl.ApplyObject(cm)
+			require.NoError(t, err)
+
+			// when
+			createdOrChanged, err := cl.ApplyObject(modifiedCm.DeepCopyObject(), client.ThreeWayMergePatch(true))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, createdOrChanged)
+			configMap := &corev1.ConfigMap{}
+			err = cli.Get(context.TODO(), namespacedName, configMap)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"first-param": "second-value"}, configMap.Data)
+		})
+
+		t.Run("it should not patch when nothing changed", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			_, err := cl.ApplyObject(defaultCm.DeepCopyObject())
+			require.NoError(t, err)
+			cli.MockPatch = func(ctx context.Context, obj runtime.Object, patch runtimeclient.Patch, opts ...runtimeclient.PatchOption) error {
+				return fmt.Errorf("should not be called")
+			}
+
+			// when
+			createdOrChanged, err := cl.ApplyObject(defaultCm.DeepCopyObject(), client.ForceUpdate(true), client.ThreeWayMergePatch(true))
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, createdOrChanged)
+		})
+
+		t.Run("it should use a JSON merge patch for custom resources", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			tier := &toolchainv1alpha1.NSTemplateTier{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "toolchain.dev.openshift.com/v1alpha1",
+					Kind:       "NSTemplateTier",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "basic",
+					Namespace: "toolchain-host-operator",
+				},
+				Spec: toolchainv1alpha1.NSTemplateTierSpec{
+					DeactivationTimeoutDays: 30,
+				},
+			}
+			obj, err := toUnstructured(tier)
+			require.NoError(t, err)
+			_, err = cl.ApplyObject(obj)
+			require.NoError(t, err)
+			patchType := recordPatchType(cli)
+
+			// when
+			tier.Spec.DeactivationTimeoutDays = 60
+			modifiedObj, err := toUnstructured(tier)
+			require.NoError(t, err)
+			createdOrChanged, err := cl.ApplyObject(modifiedObj, client.ThreeWayMergePatch(true))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, createdOrChanged)
+			assert.Equal(t, types.MergePatchType, *patchType)
+			updated := &toolchainv1alpha1.NSTemplateTier{}
+			err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "basic"}, updated)
+			require.NoError(t, err)
+			assert.Equal(t, 60, updated.Spec.DeactivationTimeoutDays)
+		})
+
+		t.Run("when patch fails, then it should return an error", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			_, err := cl.ApplyObject(defaultCm.DeepCopyObject())
+			require.NoError(t, err)
+			cli.MockPatch = func(ctx context.Context, obj runtime.Object, patch runtimeclient.Patch, opts ...runtimeclient.PatchOption) error {
+				return fmt.Errorf("unable to patch")
+			}
+
+			// when
+			createdOrChanged, err := cl.ApplyObject(modifiedCm.DeepCopyObject(), client.ThreeWayMergePatch(true))
+
+			// then
+			require.Error(t, err)
+			assert.False(t, createdOrChanged)
+			assert.Contains(t, err.Error(), "unable to patch the resource")
+		})
+	})
+
 	t.Run("updates of ConfigMaps", func(t *testing.T) {
 
 		t.Run("it should update ConfigMap when data field is different and forceUpdate=false", func(t *testing.T) {
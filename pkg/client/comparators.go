@@ -0,0 +1,299 @@
+package client
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The comparators below compare the desired object (the first one) with the live object (the second one).
+// They can be used with NewComparableToolchainObject, and they support both typed and unstructured objects.
+// The labels and annotations of the desired object must be set in the live object, but the live object can have
+// other labels and annotations (eg: set by the server or by other controllers). The last applied configuration is ignored.
+
+// CompareNamespaces compares the labels and annotations of the given Namespaces. The spec (ie, the finalizers) is populated by the server.
+func CompareNamespaces(desired, live ToolchainObject) (bool, error) {
+	return sameIdentityAndMetadata(desired, live), nil
+}
+
+// CompareConfigMaps compares the metadata and the data of the given ConfigMaps
+func CompareConfigMaps(desired, live ToolchainObject) (bool, error) {
+	desiredCM, liveCM := &corev1.ConfigMap{}, &corev1.ConfigMap{}
+	if err := toTypedObjects(desired, live, desiredCM, liveCM); err != nil {
+		return false, err
+	}
+	return sameIdentityAndMetadata(desired, live) &&
+		equality.Semantic.DeepEqual(desiredCM.Data, liveCM.Data) &&
+		equality.Semantic.DeepEqual(desiredCM.BinaryData, liveCM.BinaryData), nil
+}
+
+// CompareRoles compares the metadata and the rules of the given Roles
+func CompareRoles(desired, live ToolchainObject) (bool, error) {
+	desiredRole, liveRole := &rbacv1.Role{}, &rbacv1.Role{}
+	if err := toTypedObjects(desired, live, desiredRole, liveRole); err != nil {
+		return false, err
+	}
+	return sameIdentityAndMetadata(desired, live) &&
+		equality.Semantic.DeepEqual(desiredRole.Rules, liveRole.Rules), nil
+}
+
+// CompareClusterRoles compares the metadata and the rules of the given ClusterRoles.
+// The rules of an aggregated ClusterRole are populated by the server, so only the aggregation rule is compared in that case.
+func CompareClusterRoles(desired, live ToolchainObject) (bool, error) {
+	desiredRole, liveRole := &rbacv1.ClusterRole{}, &rbacv1.ClusterRole{}
+	if err := toTypedObjects(desired, live, desiredRole, liveRole); err != nil {
+		return false, err
+	}
+	if !sameIdentityAndMetadata(desired, live) {
+		return false, nil
+	}
+	if desiredRole.AggregationRule != nil {
+		return equality.Semantic.DeepEqual(desiredRole.AggregationRule, liveRole.AggregationRule), nil
+	}
+	return liveRole.AggregationRule == nil && equality.Semantic.DeepEqual(desiredRole.Rules, liveRole.Rules), nil
+}
+
+// CompareRoleBindings compares the metadata, the role reference and the subjects of the given RoleBindings.
+// The API group of the subjects is defaulted like the server does, if it's not set.
+func CompareRoleBindings(desired, live ToolchainObject) (bool, error) {
+	desiredBinding, liveBinding := &rbacv1.RoleBinding{}, &rbacv1.RoleBinding{}
+	if err := toTypedObjects(desired, live, desiredBinding, liveBinding); err != nil {
+		return false, err
+	}
+	return sameIdentityAndMetadata(desired, live) &&
+		desiredBinding.RoleRef.Kind == liveBinding.RoleRef.Kind &&
+		desiredBinding.RoleRef.Name == liveBinding.RoleRef.Name &&
+		equality.Semantic.DeepEqual(defaultSubjects(desiredBinding.Subjects), defaultSubjects(liveBinding.Subjects)), nil
+}
+
+// CompareLimitRanges compares the metadata and the limits of the given LimitRanges. The quantities are compared semantically (eg: `1Gi` and `1024Mi`)
+// The default values set by the API server in the limits of the containers are ignored.
+func CompareLimitRanges(desired, live ToolchainObject) (bool, error) {
+	desiredRange, liveRange := &corev1.LimitRange{}, &corev1.LimitRange{}
+	if err := toTypedObjects(desired, live, desiredRange, liveRange); err != nil {
+		return false, err
+	}
+	defaultLimitRange(desiredRange)
+	defaultLimitRange(liveRange)
+	return sameIdentityAndMetadata(desired, live) &&
+		equality.Semantic.DeepEqual(desiredRange.Spec, liveRange.Spec), nil
+}
+
+// CompareResourceQuotas compares the metadata and the spec of the given ResourceQuotas. The quantities are compared semantically.
+// The status (ie, the usage) is ignored.
+func CompareResourceQuotas(desired, live ToolchainObject) (bool, error) {
+	desiredQuota, liveQuota := &corev1.ResourceQuota{}, &corev1.ResourceQuota{}
+	if err := toTypedObjects(desired, live, desiredQuota, liveQuota); err != nil {
+		return false, err
+	}
+	return sameIdentityAndMetadata(desired, live) &&
+		equality.Semantic.DeepEqual(desiredQuota.Spec, liveQuota.Spec), nil
+}
+
+// CompareNetworkPolicies compares the metadata and the spec of the given NetworkPolicies.
+// The policy types and the protocol of the ports are defaulted like the server does, if they are not set.
+func CompareNetworkPolicies(desired, live ToolchainObject) (bool, error) {
+	desiredPolicy, livePolicy := &networkingv1.NetworkPolicy{}, &networkingv1.NetworkPolicy{}
+	if err := toTypedObjects(desired, live, desiredPolicy, livePolicy); err != nil {
+		return false, err
+	}
+	defaultNetworkPolicy(desiredPolicy)
+	defaultNetworkPolicy(livePolicy)
+	return sameIdentityAndMetadata(desired, live) &&
+		equality.Semantic.DeepEqual(desiredPolicy.Spec, livePolicy.Spec), nil
+}
+
+// CompareUnstructured compares the metadata and all the other top-level fields (apart from the status) of the given objects.
+// The fields which are not set in the desired object are ignored, so the defaults populated by the server are not a difference.
+func CompareUnstructured(desired, live ToolchainObject) (bool, error) {
+	desiredContent, err := toUnstructuredContent(desired.GetRuntimeObject())
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to convert the object '%s'", desired.GetName())
+	}
+	liveContent, err := toUnstructuredContent(live.GetRuntimeObject())
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to convert the object '%s'", live.GetName())
+	}
+	if !sameIdentityAndMetadata(desired, live) {
+		return false, nil
+	}
+	for key, value := range desiredContent {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		if !isSubset(value, liveContent[key]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+var comparators = map[schema.GroupKind]CompareToolchainObjects{
+	{Group: "", Kind: "Namespace"}:                                   CompareNamespaces,
+	{Group: "", Kind: "ConfigMap"}:                                   CompareConfigMaps,
+	{Group: "", Kind: "LimitRange"}:                                  CompareLimitRanges,
+	{Group: "", Kind: "ResourceQuota"}:                               CompareResourceQuotas,
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"}:               CompareRoles,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:        CompareClusterRoles,
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:        CompareRoleBindings,
+	{Group: "networking.k8s.io", Kind: "NetworkPolicy"}:              CompareNetworkPolicies,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: CompareRoleBindings,
+}
+
+// CompareByKind compares the given objects with the comparator of the kind of the desired object,
+// or with CompareUnstructured if there is no specific comparator for this kind
+func CompareByKind(desired, live ToolchainObject) (bool, error) {
+	if compare, ok := comparators[desired.GetGvk().GroupKind()]; ok {
+		return compare(desired, live)
+	}
+	return CompareUnstructured(desired, live)
+}
+
+// sameIdentityAndMetadata checks that the objects have the same kind, namespace and name, and that the labels and annotations
+// of the desired object are set in the live object (apart from the last applied configuration)
+func sameIdentityAndMetadata(desired, live ToolchainObject) bool {
+	if desired.GetGvk().GroupKind() != live.GetGvk().GroupKind() ||
+		desired.GetNamespace() != live.GetNamespace() || desired.GetName() != live.GetName() {
+		return false
+	}
+	for key, value := range desired.GetLabels() {
+		if liveValue, found := live.GetLabels()[key]; !found || liveValue != value {
+			return false
+		}
+	}
+	for key, value := range desired.GetAnnotations() {
+		if key == LastAppliedConfigurationAnnotationKey {
+			continue
+		}
+		if liveValue, found := live.GetAnnotations()[key]; !found || liveValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// toTypedObjects converts the given objects (typed or unstructured) into the given typed objects
+func toTypedObjects(desired, live ToolchainObject, desiredTyped, liveTyped runtime.Object) error {
+	if err := toTypedObject(desired, desiredTyped); err != nil {
+		return err
+	}
+	return toTypedObject(live, liveTyped)
+}
+
+func toTypedObject(obj ToolchainObject, typed runtime.Object) error {
+	content, err := toUnstructuredContent(obj.GetRuntimeObject())
+	if err != nil {
+		return errors.Wrapf(err, "unable to convert the object '%s'", obj.GetName())
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, typed); err != nil {
+		return errors.Wrapf(err, "unable to convert the object '%s' into %T", obj.GetName(), typed)
+	}
+	return nil
+}
+
+func defaultSubjects(subjects []rbacv1.Subject) []rbacv1.Subject {
+	defaulted := make([]rbacv1.Subject, len(subjects))
+	for i, subject := range subjects {
+		defaulted[i] = subject
+		if subject.APIGroup == "" && (subject.Kind == rbacv1.UserKind || subject.Kind == rbacv1.GroupKind) {
+			defaulted[i].APIGroup = rbacv1.GroupName
+		}
+	}
+	return defaulted
+}
+
+func defaultNetworkPolicy(policy *networkingv1.NetworkPolicy) {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		policy.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		if len(policy.Spec.Egress) > 0 {
+			policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		}
+	}
+	tcp := corev1.ProtocolTCP
+	defaultPorts := func(ports []networkingv1.NetworkPolicyPort) {
+		for i := range ports {
+			if ports[i].Protocol == nil {
+				ports[i].Protocol = &tcp
+			}
+		}
+	}
+	for i := range policy.Spec.Ingress {
+		defaultPorts(policy.Spec.Ingress[i].Ports)
+	}
+	for i := range policy.Spec.Egress {
+		defaultPorts(policy.Spec.Egress[i].Ports)
+	}
+}
+
+// defaultLimitRange sets the default values which are set by the API server in the limits of the containers:
+// the default limits are set from the max, and the default requests from the default limits or (if missing) from the min
+func defaultLimitRange(limitRange *corev1.LimitRange) {
+	for i := range limitRange.Spec.Limits {
+		item := &limitRange.Spec.Limits[i]
+		if item.Type != corev1.LimitTypeContainer {
+			continue
+		}
+		if item.Default == nil {
+			item.Default = corev1.ResourceList{}
+		}
+		if item.DefaultRequest == nil {
+			item.DefaultRequest = corev1.ResourceList{}
+		}
+		for name, value := range item.Max {
+			if _, exists := item.Default[name]; !exists {
+				item.Default[name] = value.DeepCopy()
+			}
+		}
+		for name, value := range item.Default {
+			if _, exists := item.DefaultRequest[name]; !exists {
+				item.DefaultRequest[name] = value.DeepCopy()
+			}
+		}
+		for name, value := range item.Min {
+			if _, exists := item.DefaultRequest[name]; !exists {
+				item.DefaultRequest[name] = value.DeepCopy()
+			}
+		}
+	}
+}
+
+// isSubset checks if the given desired value is set in the given live value. The maps of the live value can have more entries,
+// but the lists must have the same length
+func isSubset(desired, live interface{}) bool {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			return len(desiredValue) == 0 && live == nil
+		}
+		for key, value := range desiredValue {
+			if !isSubset(value, liveValue[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok {
+			return len(desiredValue) == 0 && live == nil
+		}
+		if len(desiredValue) != len(liveValue) {
+			return false
+		}
+		for i := range desiredValue {
+			if !isSubset(desiredValue[i], liveValue[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(desired, live)
+	}
+}
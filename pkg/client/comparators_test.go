@@ -0,0 +1,495 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCompareNamespaces(t *testing.T) {
+	// given
+	desired := newComparatorNamespace()
+	desired.Annotations = map[string]string{client.LastAppliedConfigurationAnnotationKey: "{}"}
+
+	t.Run("should be same when the server added metadata and finalizers", func(t *testing.T) {
+		// given
+		live := newComparatorNamespace()
+		live.Labels["openshift.io/run-level"] = "0"
+		live.Annotations = map[string]string{"openshift.io/sa.scc.mcs": "s0:c1,c0"}
+		live.Spec.Finalizers = []corev1.FinalizerName{corev1.FinalizerKubernetes}
+		live.ResourceVersion = "123"
+		live.UID = "abc"
+
+		// when
+		same, err := client.CompareNamespaces(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when a label is different", func(t *testing.T) {
+		// given
+		live := newComparatorNamespace()
+		live.Labels["toolchain.dev.openshift.com/owner"] = "jane"
+
+		// when
+		same, err := client.CompareNamespaces(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+
+	t.Run("should not be same when the name is different", func(t *testing.T) {
+		// given
+		live := newComparatorNamespace()
+		live.Name = "jane-dev"
+
+		// when
+		same, err := client.CompareNamespaces(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareConfigMaps(t *testing.T) {
+	// given
+	desired := newComparatorConfigMap()
+
+	t.Run("should be same when the live object is unstructured", func(t *testing.T) {
+		// given
+		live := mustToUnstructured(t, newComparatorConfigMap())
+
+		// when
+		same, err := client.CompareConfigMaps(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the data is different", func(t *testing.T) {
+		// given
+		live := newComparatorConfigMap()
+		live.Data["another-param"] = "another-value"
+
+		// when
+		same, err := client.CompareConfigMaps(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareRoles(t *testing.T) {
+	// given
+	desired := newComparatorRole()
+
+	t.Run("should be same", func(t *testing.T) {
+		// when
+		same, err := client.CompareRoles(toToolchainObject(t, desired), toToolchainObject(t, newComparatorRole()))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the rules are different", func(t *testing.T) {
+		// given
+		live := newComparatorRole()
+		live.Rules[0].Verbs = append(live.Rules[0].Verbs, "delete")
+
+		// when
+		same, err := client.CompareRoles(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareClusterRoles(t *testing.T) {
+
+	t.Run("should ignore the rules populated by the server for an aggregated cluster role", func(t *testing.T) {
+		// given
+		desired := newComparatorClusterRole()
+		desired.AggregationRule = &rbacv1.AggregationRule{
+			ClusterRoleSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"aggregate-to-edit": "true"}}},
+		}
+		live := desired.DeepCopy()
+		live.Rules = newComparatorRole().Rules
+
+		// when
+		same, err := client.CompareClusterRoles(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the rules are different", func(t *testing.T) {
+		// given
+		desired := newComparatorClusterRole()
+		desired.Rules = newComparatorRole().Rules
+		live := newComparatorClusterRole()
+
+		// when
+		same, err := client.CompareClusterRoles(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareRoleBindings(t *testing.T) {
+	// given
+	desired := newComparatorRoleBinding()
+	desired.Subjects[0].APIGroup = ""
+
+	t.Run("should be same when the server defaulted the API group of the subjects", func(t *testing.T) {
+		// when
+		same, err := client.CompareRoleBindings(toToolchainObject(t, desired), toToolchainObject(t, newComparatorRoleBinding()))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the subjects are different", func(t *testing.T) {
+		// given
+		live := newComparatorRoleBinding()
+		live.Subjects[0].Name = "jane"
+
+		// when
+		same, err := client.CompareRoleBindings(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+
+	t.Run("should not be same when the role is different", func(t *testing.T) {
+		// given
+		live := newComparatorRoleBinding()
+		live.RoleRef.Name = "admin"
+
+		// when
+		same, err := client.CompareRoleBindings(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareLimitRanges(t *testing.T) {
+	// given
+	desired := newComparatorLimitRange("1Gi")
+
+	t.Run("should be same when the quantities are semantically equal", func(t *testing.T) {
+		// when
+		same, err := client.CompareLimitRanges(toToolchainObject(t, desired), toToolchainObject(t, newComparatorLimitRange("1024Mi")))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the limits are different", func(t *testing.T) {
+		// when
+		same, err := client.CompareLimitRanges(toToolchainObject(t, desired), toToolchainObject(t, newComparatorLimitRange("2Gi")))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+
+	t.Run("should be same when the live object has the default values set by the server", func(t *testing.T) {
+		// given
+		desired := newComparatorLimitRange("1Gi")
+		desired.Spec.Limits[0].Max = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+		desired.Spec.Limits[0].Min = corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("1Mi")}
+		live := desired.DeepCopy()
+		live.Spec.Limits[0].Default[corev1.ResourceCPU] = resource.MustParse("2")
+		live.Spec.Limits[0].DefaultRequest = corev1.ResourceList{
+			corev1.ResourceMemory:           resource.MustParse("1Gi"),
+			corev1.ResourceCPU:              resource.MustParse("2"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("1Mi"),
+		}
+
+		// when
+		same, err := client.CompareLimitRanges(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the live object has a different default request", func(t *testing.T) {
+		// given
+		live := newComparatorLimitRange("1Gi")
+		live.Spec.Limits[0].DefaultRequest = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}
+
+		// when
+		same, err := client.CompareLimitRanges(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareResourceQuotas(t *testing.T) {
+	// given
+	desired := newComparatorResourceQuota("4")
+
+	t.Run("should be same when the status is set", func(t *testing.T) {
+		// given
+		live := newComparatorResourceQuota("4000m")
+		live.Status.Used = corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("1")}
+
+		// when
+		same, err := client.CompareResourceQuotas(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the hard limits are different", func(t *testing.T) {
+		// when
+		same, err := client.CompareResourceQuotas(toToolchainObject(t, desired), toToolchainObject(t, newComparatorResourceQuota("2")))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareNetworkPolicies(t *testing.T) {
+	// given
+	desired := newComparatorNetworkPolicy()
+
+	t.Run("should be same when the server defaulted the policy types and the protocols", func(t *testing.T) {
+		// given
+		live := newComparatorNetworkPolicy()
+		tcp := corev1.ProtocolTCP
+		live.Spec.Ingress[0].Ports[0].Protocol = &tcp
+		live.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+
+		// when
+		same, err := client.CompareNetworkPolicies(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when the protocol is different", func(t *testing.T) {
+		// given
+		live := newComparatorNetworkPolicy()
+		udp := corev1.ProtocolUDP
+		live.Spec.Ingress[0].Ports[0].Protocol = &udp
+
+		// when
+		same, err := client.CompareNetworkPolicies(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareUnstructured(t *testing.T) {
+	// given
+	desired := mustToUnstructured(t, newComparatorConfigMap())
+
+	t.Run("should be same when the live object has extra fields", func(t *testing.T) {
+		// given
+		live := mustToUnstructured(t, newComparatorConfigMap())
+		err := unstructured.SetNestedField(live.Object, "server-value", "data", "server-param")
+		require.NoError(t, err)
+		err = unstructured.SetNestedField(live.Object, "Active", "status", "phase")
+		require.NoError(t, err)
+
+		// when
+		same, err := client.CompareUnstructured(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should not be same when a field is different", func(t *testing.T) {
+		// given
+		live := mustToUnstructured(t, newComparatorConfigMap())
+		err := unstructured.SetNestedField(live.Object, "another-value", "data", "first-param")
+		require.NoError(t, err)
+
+		// when
+		same, err := client.CompareUnstructured(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, same)
+	})
+}
+
+func TestCompareByKind(t *testing.T) {
+
+	t.Run("should use the comparator of the kind", func(t *testing.T) {
+		// given
+		desired := newComparatorRoleBinding()
+		desired.Subjects[0].APIGroup = ""
+
+		// when
+		same, err := client.CompareByKind(toToolchainObject(t, desired), toToolchainObject(t, newComparatorRoleBinding()))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+
+	t.Run("should fall back to the unstructured comparator", func(t *testing.T) {
+		// given
+		desired := &corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{Kind: "ServiceAccount", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "john", Namespace: "john-dev"},
+		}
+		live := desired.DeepCopy()
+		live.Secrets = []corev1.ObjectReference{{Name: "john-token"}}
+
+		// when
+		same, err := client.CompareByKind(toToolchainObject(t, desired), toToolchainObject(t, live))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, same)
+	})
+}
+
+func toToolchainObject(t *testing.T, obj runtime.Object) client.ToolchainObject {
+	toolchainObject, err := client.NewToolchainObject(obj)
+	require.NoError(t, err)
+	return toolchainObject
+}
+
+func mustToUnstructured(t *testing.T, obj runtime.Object) *unstructured.Unstructured {
+	result, err := toUnstructured(obj)
+	require.NoError(t, err)
+	return result
+}
+
+func newComparatorNamespace() *corev1.Namespace {
+	return &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "john-dev",
+			Labels: map[string]string{"toolchain.dev.openshift.com/owner": "john"},
+		},
+	}
+}
+
+func newComparatorConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "john-dev"},
+		Data:       map[string]string{"first-param": "first-value"},
+	}
+}
+
+func newComparatorRole() *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{Kind: "Role", APIVersion: "rbac.authorization.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "toolchain-dev-edit", Namespace: "john-dev"},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get", "list"},
+			},
+		},
+	}
+}
+
+func newComparatorClusterRole() *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{Kind: "ClusterRole", APIVersion: "rbac.authorization.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "toolchain-edit"},
+	}
+}
+
+func newComparatorRoleBinding() *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{Kind: "RoleBinding", APIVersion: "rbac.authorization.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "john-edit", Namespace: "john-dev"},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     "edit",
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				APIGroup: rbacv1.GroupName,
+				Kind:     rbacv1.UserKind,
+				Name:     "john",
+			},
+		},
+	}
+}
+
+func newComparatorLimitRange(memory string) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		TypeMeta:   metav1.TypeMeta{Kind: "LimitRange", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "resource-limits", Namespace: "john-dev"},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:    corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+				},
+			},
+		},
+	}
+}
+
+func newComparatorResourceQuota(cpu string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		TypeMeta:   metav1.TypeMeta{Kind: "ResourceQuota", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "compute-resources", Namespace: "john-dev"},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse(cpu)},
+		},
+	}
+}
+
+func newComparatorNetworkPolicy() *networkingv1.NetworkPolicy {
+	port := intstr.FromInt(8080)
+	return &networkingv1.NetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{Kind: "NetworkPolicy", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-same-namespace", Namespace: "john-dev"},
+		Spec: networkingv1.NetworkPolicySpec{
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &port}},
+				},
+			},
+		},
+	}
+}
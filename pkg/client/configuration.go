@@ -0,0 +1,107 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+)
+
+// ConfigurationStorageMode the way the last applied configuration is stored in the resource annotations
+type ConfigurationStorageMode string
+
+const (
+	// StoreConfigurationAsJSON stores the whole configuration as plain JSON
+	StoreConfigurationAsJSON ConfigurationStorageMode = "json"
+	// StoreConfigurationAsHash stores only a stable hash of the configuration. The changes can still be detected, but the
+	// dry-run cannot detect the fields that were removed from the desired object, and the resource is updated (instead of patched)
+	// even when the three-way merge patch is enabled, so these fields are removed
+	StoreConfigurationAsHash ConfigurationStorageMode = "hash"
+	// StoreConfigurationAsGzip stores the whole configuration, gzipped and base64-encoded
+	StoreConfigurationAsGzip ConfigurationStorageMode = "gzip"
+)
+
+const (
+	hashedConfigurationPrefix  = "sha256:"
+	gzippedConfigurationPrefix = "gzip+base64:"
+)
+
+// encodeConfiguration returns the value of the annotation for the given configuration (in JSON) and storage mode
+func encodeConfiguration(configuration string, mode ConfigurationStorageMode) (string, error) {
+	switch mode {
+	case StoreConfigurationAsHash:
+		return hashedConfigurationPrefix + hashConfiguration(configuration), nil
+	case StoreConfigurationAsGzip:
+		buf := &bytes.Buffer{}
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write([]byte(configuration)); err != nil {
+			return "", errors.Wrap(err, "unable to compress the configuration")
+		}
+		if err := w.Close(); err != nil {
+			return "", errors.Wrap(err, "unable to compress the configuration")
+		}
+		return gzippedConfigurationPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+	default:
+		return configuration, nil
+	}
+}
+
+// decodeConfiguration returns the configuration (in JSON) stored in the given annotation value, regardless of the storage mode.
+// It returns `nil` if the annotation value is empty or if it contains only a hash of the configuration
+func decodeConfiguration(value string) ([]byte, error) {
+	switch {
+	case isHashedConfiguration(value):
+		return nil, nil
+	case strings.HasPrefix(value, gzippedConfigurationPrefix):
+		compressed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, gzippedConfigurationPrefix))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decode the configuration")
+		}
+		r, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decompress the configuration")
+		}
+		defer r.Close()
+		configuration, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decompress the configuration")
+		}
+		return configuration, nil
+	case value == "":
+		return nil, nil
+	default:
+		return []byte(value), nil
+	}
+}
+
+// isHashedConfiguration checks if the given annotation value contains only a hash of the configuration
+func isHashedConfiguration(value string) bool {
+	return strings.HasPrefix(value, hashedConfigurationPrefix)
+}
+
+// sameConfiguration checks if the given annotation value (in any storage mode) matches the given configuration (in JSON)
+func sameConfiguration(logger logr.Logger, value, configuration string) bool {
+	if isHashedConfiguration(value) {
+		return strings.TrimPrefix(value, hashedConfigurationPrefix) == hashConfiguration(configuration)
+	}
+	if strings.HasPrefix(value, gzippedConfigurationPrefix) {
+		decoded, err := decodeConfiguration(value)
+		if err != nil {
+			logger.Error(err, "unable to decode the last applied configuration")
+			return false
+		}
+		return string(decoded) == configuration
+	}
+	return value == configuration
+}
+
+func hashConfiguration(configuration string) string {
+	hash := sha256.Sum256([]byte(configuration))
+	return hex.EncodeToString(hash[:])
+}
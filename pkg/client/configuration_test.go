@@ -0,0 +1,184 @@
+package client_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStoreConfiguration(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	namespacedName := types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}
+	defaultCm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registration-service",
+			Namespace: "toolchain-host-operator",
+		},
+		Data: map[string]string{
+			"first-param":  "first-value",
+			"second-param": "second-value",
+		},
+	}
+	modifiedCm := defaultCm.DeepCopy()
+	delete(modifiedCm.Data, "second-param")
+
+	for _, mode := range []client.ConfigurationStorageMode{client.StoreConfigurationAsHash, client.StoreConfigurationAsGzip} {
+
+		t.Run(string(mode), func(t *testing.T) {
+
+			t.Run("should not store the plain configuration", func(t *testing.T) {
+				// given
+				cl, cli := newClient(t, s)
+
+				// when
+				createdOrChanged, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(mode))
+
+				// then
+				require.NoError(t, err)
+				assert.True(t, createdOrChanged)
+				configMap := &corev1.ConfigMap{}
+				err = cli.Get(context.TODO(), namespacedName, configMap)
+				require.NoError(t, err)
+				configuration := configMap.Annotations[client.LastAppliedConfigurationAnnotationKey]
+				assert.NotEmpty(t, configuration)
+				assert.NotContains(t, configuration, "first-value")
+			})
+
+			t.Run("should not update when using same object", func(t *testing.T) {
+				// given
+				cl, _ := newClient(t, s)
+				_, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(mode))
+				require.NoError(t, err)
+
+				// when
+				createdOrChanged, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(mode))
+
+				// then
+				require.NoError(t, err)
+				assert.False(t, createdOrChanged)
+			})
+
+			t.Run("should update when object is different", func(t *testing.T) {
+				// given
+				cl, cli := newClient(t, s)
+				_, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(mode))
+				require.NoError(t, err)
+
+				// when
+				createdOrChanged, err := cl.ApplyObject(modifiedCm.DeepCopy(), client.StoreConfiguration(mode))
+
+				// then
+				require.NoError(t, err)
+				assert.True(t, createdOrChanged)
+				configMap := &corev1.ConfigMap{}
+				err = cli.Get(context.TODO(), namespacedName, configMap)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"first-param": "first-value"}, configMap.Data)
+			})
+		})
+	}
+
+	t.Run("hash should be stable", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		_, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(client.StoreConfigurationAsHash))
+		require.NoError(t, err)
+		first := &corev1.ConfigMap{}
+		err = cli.Get(context.TODO(), namespacedName, first)
+		require.NoError(t, err)
+
+		// when
+		_, err = cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(client.StoreConfigurationAsHash), client.ForceUpdate(true))
+
+		// then
+		require.NoError(t, err)
+		second := &corev1.ConfigMap{}
+		err = cli.Get(context.TODO(), namespacedName, second)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(first.Annotations[client.LastAppliedConfigurationAnnotationKey], "sha256:"))
+		assert.Equal(t, first.Annotations[client.LastAppliedConfigurationAnnotationKey], second.Annotations[client.LastAppliedConfigurationAnnotationKey])
+	})
+
+	t.Run("should read existing plain JSON configuration", func(t *testing.T) {
+		// given
+		cl, _ := newClient(t, s)
+		_, err := cl.ApplyObject(defaultCm.DeepCopy())
+		require.NoError(t, err)
+
+		for _, mode := range []client.ConfigurationStorageMode{client.StoreConfigurationAsHash, client.StoreConfigurationAsGzip} {
+			// when
+			createdOrChanged, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(mode))
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, createdOrChanged)
+		}
+	})
+
+	t.Run("should remove fields with three-way merge patch when configuration is gzipped", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		_, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(client.StoreConfigurationAsGzip))
+		require.NoError(t, err)
+
+		// when
+		createdOrChanged, err := cl.ApplyObject(modifiedCm.DeepCopy(), client.StoreConfiguration(client.StoreConfigurationAsGzip), client.ThreeWayMergePatch(true))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, createdOrChanged)
+		configMap := &corev1.ConfigMap{}
+		err = cli.Get(context.TODO(), namespacedName, configMap)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"first-param": "first-value"}, configMap.Data)
+	})
+
+	t.Run("should remove fields with three-way merge patch when configuration is hashed", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		_, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(client.StoreConfigurationAsHash))
+		require.NoError(t, err)
+		modified := modifiedCm.DeepCopy()
+		modified.Data["first-param"] = "modified-value"
+
+		// when
+		createdOrChanged, err := cl.ApplyObject(modified, client.StoreConfiguration(client.StoreConfigurationAsHash), client.ThreeWayMergePatch(true))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, createdOrChanged)
+		configMap := &corev1.ConfigMap{}
+		err = cli.Get(context.TODO(), namespacedName, configMap)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"first-param": "modified-value"}, configMap.Data)
+	})
+
+	t.Run("should report removed fields in dry-run when configuration is gzipped", func(t *testing.T) {
+		// given
+		cl, _ := newClient(t, s)
+		_, err := cl.ApplyObject(defaultCm.DeepCopy(), client.StoreConfiguration(client.StoreConfigurationAsGzip))
+		require.NoError(t, err)
+
+		// when
+		result, err := cl.DryRunApplyObject(modifiedCm.DeepCopy())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []client.FieldDiff{
+			{
+				Path:    "data.second-param",
+				Live:    "second-value",
+				Desired: nil,
+			},
+		}, result.Diff)
+	})
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+)
+
+type loggerContextKey struct{}
+
+// ContextWithLogger returns a copy of the given context which carries the given logger,
+// to be used by the context-aware functions of the ApplyClient instead of the default logger
+func ContextWithLogger(ctx context.Context, logger logr.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// loggerFromContext returns the logger carried by the given context, or the default logger if there is none
+func loggerFromContext(ctx context.Context) logr.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(logr.Logger); ok {
+		return logger
+	}
+	return log
+}
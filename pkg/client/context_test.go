@@ -0,0 +1,170 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	logrtesting "github.com/go-logr/logr/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type contextKey string
+
+type errorRecordingLogger struct {
+	logrtesting.NullLogger
+	messages *[]string
+}
+
+func (l errorRecordingLogger) Error(_ error, msg string, _ ...interface{}) {
+	*l.messages = append(*l.messages, msg)
+}
+
+func TestApplyObjectWithContext(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	newConfigMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "registration-service",
+				Namespace: "toolchain-host-operator",
+			},
+			Data: map[string]string{
+				"first-param": "first-value",
+			},
+		}
+	}
+
+	t.Run("should propagate the context to the client", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		ctx := context.WithValue(context.Background(), contextKey("request"), "abcd")
+		var values []interface{}
+		cli.MockGet = func(ctx context.Context, key types.NamespacedName, obj runtime.Object) error {
+			values = append(values, ctx.Value(contextKey("request")))
+			return cli.Client.Get(ctx, key, obj)
+		}
+		cli.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+			values = append(values, ctx.Value(contextKey("request")))
+			return Create(ctx, cli, obj, opts...)
+		}
+
+		// when
+		createdOrChanged, err := cl.ApplyObjectWithContext(ctx, newConfigMap())
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, createdOrChanged)
+		assert.Equal(t, []interface{}{"abcd", "abcd"}, values)
+	})
+
+	t.Run("should fail when the context is canceled", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		cli.MockGet = func(ctx context.Context, key types.NamespacedName, obj runtime.Object) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return cli.Client.Get(ctx, key, obj)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// when
+		createdOrChanged, err := cl.ApplyObjectWithContext(ctx, newConfigMap())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to get the resource")
+		assert.Contains(t, err.Error(), context.Canceled.Error())
+		assert.False(t, createdOrChanged)
+	})
+
+	t.Run("should use the logger of the context", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		existing := newConfigMap()
+		existing.Annotations = map[string]string{
+			client.LastAppliedConfigurationAnnotationKey: "gzip+base64:not-base64",
+		}
+		err := cli.Create(context.TODO(), existing)
+		require.NoError(t, err)
+		var messages []string
+		ctx := client.ContextWithLogger(context.Background(), errorRecordingLogger{messages: &messages})
+
+		// when
+		_, err = cl.ApplyObjectWithContext(ctx, newConfigMap())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"unable to decode the last applied configuration"}, messages)
+	})
+}
+
+func TestApplyToolchainObjectsWithContext(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	labels := newLabels("basic", "john", "dev")
+	newObjects := func(t *testing.T) []client.ToolchainObject {
+		objs := make([]client.ToolchainObject, 2)
+		for i, name := range []string{"first", "second"} {
+			obj, err := client.NewToolchainObject(&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "john-dev"},
+			})
+			require.NoError(t, err)
+			objs[i] = obj
+		}
+		return objs
+	}
+
+	t.Run("should apply all objects", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+
+		// when
+		createdOrUpdated, err := cl.ApplyToolchainObjectsWithContext(context.Background(), newObjects(t), labels)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, createdOrUpdated)
+		for _, name := range []string{"first", "second"} {
+			configMap := &corev1.ConfigMap{}
+			err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "john-dev", Name: name}, configMap)
+			require.NoError(t, err)
+			assert.Equal(t, "basic", configMap.Labels["toolchain.dev.openshift.com/tier"])
+		}
+	})
+
+	t.Run("should not apply the remaining objects when the deadline is exceeded", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		cli.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+			<-ctx.Done()
+			return Create(ctx, cli, obj, opts...)
+		}
+
+		// when
+		createdOrUpdated, err := cl.ApplyToolchainObjectsWithContext(ctx, newObjects(t), labels)
+
+		// then
+		require.EqualError(t, err, "unable to create resource of kind: ConfigMap, version: v1: context deadline exceeded")
+		assert.False(t, createdOrUpdated)
+		configMap := &corev1.ConfigMap{}
+		err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "john-dev", Name: "first"}, configMap)
+		require.NoError(t, err)
+		err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "john-dev", Name: "second"}, configMap)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type deleteConfiguration struct {
+	propagationPolicy             *v1.DeletionPropagation
+	preconditionOnUID             bool
+	preconditionOnResourceVersion bool
+	waitTimeout                   time.Duration
+	waitInterval                  time.Duration
+}
+
+func newDeleteConfiguration(options ...DeleteOption) deleteConfiguration {
+	config := deleteConfiguration{
+		propagationPolicy: nil,
+		waitTimeout:       0,
+		waitInterval:      time.Second,
+	}
+	for _, apply := range options {
+		apply(&config)
+	}
+	return config
+}
+
+// DeleteOption an option when deleting resources
+type DeleteOption func(*deleteConfiguration)
+
+// PropagationPolicy sets the policy for the deletion of the dependents of the resources, ie, `v1.DeletePropagationForeground`,
+// `v1.DeletePropagationBackground` or `v1.DeletePropagationOrphan` (default: `nil`, ie, the default policy of the resource kind)
+func PropagationPolicy(policy v1.DeletionPropagation) DeleteOption {
+	return func(config *deleteConfiguration) {
+		config.propagationPolicy = &policy
+	}
+}
+
+// PreconditionOnUID deletes the resources only if their UID matches the UID of the given objects (default: `false`)
+func PreconditionOnUID(preconditionOnUID bool) DeleteOption {
+	return func(config *deleteConfiguration) {
+		config.preconditionOnUID = preconditionOnUID
+	}
+}
+
+// PreconditionOnResourceVersion deletes the resources only if their resourceVersion matches the resourceVersion
+// of the given objects, ie, if they were not modified in the meantime (default: `false`)
+func PreconditionOnResourceVersion(preconditionOnResourceVersion bool) DeleteOption {
+	return func(config *deleteConfiguration) {
+		config.preconditionOnResourceVersion = preconditionOnResourceVersion
+	}
+}
+
+// WaitForDeletion waits until the resources are fully removed from the cluster (ie, until their finalizers and
+// their dependents with the foreground propagation were processed), polling them at the given interval
+// until the given timeout (default: `0`, ie, no wait)
+func WaitForDeletion(timeout, interval time.Duration) DeleteOption {
+	return func(config *deleteConfiguration) {
+		config.waitTimeout = timeout
+		config.waitInterval = interval
+	}
+}
+
+// DeleteResult the result of the deletion of a resource
+type DeleteResult struct {
+	Object ToolchainObject
+	// AlreadyDeleted is `true` if the resource did not exist anymore
+	AlreadyDeleted bool
+}
+
+// DeleteToolchainObjects deletes the given objects and returns the result for each of them, in the same order.
+// The objects which do not exist anymore are reported as already deleted.
+// If the `WaitForDeletion` option is set, then it returns an error if some objects still exist when the timeout is reached.
+func (p ApplyClient) DeleteToolchainObjects(toolchainObjects []ToolchainObject, options ...DeleteOption) ([]DeleteResult, error) {
+	return p.DeleteToolchainObjectsWithContext(context.TODO(), toolchainObjects, options...)
+}
+
+// DeleteToolchainObjectsWithContext does the same as DeleteToolchainObjects, but all the calls to the API server use the given context.
+// The wait for the deletion (see `WaitForDeletion`) stops as soon as the context is canceled or its deadline is exceeded.
+func (p ApplyClient) DeleteToolchainObjectsWithContext(ctx context.Context, toolchainObjects []ToolchainObject, options ...DeleteOption) ([]DeleteResult, error) {
+	logger := loggerFromContext(ctx)
+	config := newDeleteConfiguration(options...)
+	results := make([]DeleteResult, 0, len(toolchainObjects))
+	for _, toolchainObject := range toolchainObjects {
+		gvk := toolchainObject.GetGvk()
+		if err := p.cl.Delete(ctx, toolchainObject.GetRuntimeObject(), config.deleteOptions(toolchainObject)...); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return results, errors.Wrapf(err, "unable to delete the resource of kind: %s, version: %s, namespace: %s, name: %s",
+					gvk.Kind, gvk.Version, toolchainObject.GetNamespace(), toolchainObject.GetName())
+			}
+			results = append(results, DeleteResult{Object: toolchainObject, AlreadyDeleted: true})
+			continue
+		}
+		logger.Info("deleted resource", "kind", gvk.Kind, "namespace", toolchainObject.GetNamespace(), "name", toolchainObject.GetName())
+		results = append(results, DeleteResult{Object: toolchainObject})
+	}
+	if config.waitTimeout <= 0 {
+		return results, nil
+	}
+	return results, p.waitForDeletion(ctx, results, config)
+}
+
+func (c deleteConfiguration) deleteOptions(toolchainObject ToolchainObject) []client.DeleteOption {
+	var options []client.DeleteOption
+	if c.propagationPolicy != nil {
+		options = append(options, client.PropagationPolicy(*c.propagationPolicy))
+	}
+	if c.preconditionOnUID || c.preconditionOnResourceVersion {
+		preconditions := client.Preconditions{}
+		if c.preconditionOnUID {
+			uid := toolchainObject.GetUID()
+			preconditions.UID = &uid
+		}
+		if c.preconditionOnResourceVersion {
+			resourceVersion := toolchainObject.GetResourceVersion()
+			preconditions.ResourceVersion = &resourceVersion
+		}
+		options = append(options, preconditions)
+	}
+	return options
+}
+
+// waitForDeletion polls the deleted resources until none of them exists anymore, or until the timeout is reached
+// or the given context is canceled
+func (p ApplyClient) waitForDeletion(ctx context.Context, results []DeleteResult, config deleteConfiguration) error {
+	pollCtx, cancel := context.WithTimeout(ctx, config.waitTimeout)
+	defer cancel()
+	var remaining []string
+	err := wait.PollImmediateUntil(config.waitInterval, func() (bool, error) {
+		remaining = nil
+		for _, result := range results {
+			if result.AlreadyDeleted {
+				continue
+			}
+			obj := result.Object.GetRuntimeObject().DeepCopyObject()
+			namespacedName := types.NamespacedName{Namespace: result.Object.GetNamespace(), Name: result.Object.GetName()}
+			if err := p.cl.Get(pollCtx, namespacedName, obj); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return false, errors.Wrapf(err, "unable to get the resource '%s'", namespacedName)
+			}
+			remaining = append(remaining, fmt.Sprintf("%s %s", result.Object.GetGvk().Kind, namespacedName))
+		}
+		return len(remaining) == 0, nil
+	}, pollCtx.Done())
+	if err == nil || pollCtx.Err() == nil {
+		return err
+	}
+	// the poll was stopped, either by the cancellation of the given context or by the timeout
+	if ctx.Err() != nil {
+		return errors.Wrapf(ctx.Err(), "stopped waiting for the deletion of the resources: %s", strings.Join(remaining, ", "))
+	}
+	return fmt.Errorf("the resources were not deleted within %s: %s", config.waitTimeout, strings.Join(remaining, ", "))
+}
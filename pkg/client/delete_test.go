@@ -0,0 +1,176 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDeleteToolchainObjects(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	newConfigMap := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "john-dev",
+				UID:             types.UID(name + "-uid"),
+				ResourceVersion: "1",
+			},
+		}
+	}
+	newObjects := func(t *testing.T, names ...string) []client.ToolchainObject {
+		objs := make([]client.ToolchainObject, len(names))
+		for i, name := range names {
+			obj, err := client.NewToolchainObject(newConfigMap(name))
+			require.NoError(t, err)
+			objs[i] = obj
+		}
+		return objs
+	}
+
+	t.Run("should delete objects and report the ones already deleted", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t, newConfigMap("first"))
+
+		// when
+		results, err := client.NewApplyClient(cl, s).DeleteToolchainObjects(newObjects(t, "first", "second"))
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, "first", results[0].Object.GetName())
+		assert.False(t, results[0].AlreadyDeleted)
+		assert.Equal(t, "second", results[1].Object.GetName())
+		assert.True(t, results[1].AlreadyDeleted)
+		err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "john-dev", Name: "first"}, &corev1.ConfigMap{})
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("should delete with propagation policy and preconditions", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t, newConfigMap("first"))
+		deleteOptions := &runtimeclient.DeleteOptions{}
+		cl.MockDelete = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.DeleteOption) error {
+			deleteOptions.ApplyOptions(opts)
+			return cl.Client.Delete(ctx, obj, opts...)
+		}
+
+		// when
+		_, err := client.NewApplyClient(cl, s).DeleteToolchainObjects(newObjects(t, "first"),
+			client.PropagationPolicy(metav1.DeletePropagationForeground),
+			client.PreconditionOnUID(true),
+			client.PreconditionOnResourceVersion(true))
+
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, deleteOptions.PropagationPolicy)
+		assert.Equal(t, metav1.DeletePropagationForeground, *deleteOptions.PropagationPolicy)
+		require.NotNil(t, deleteOptions.Preconditions)
+		assert.Equal(t, types.UID("first-uid"), *deleteOptions.Preconditions.UID)
+		assert.Equal(t, "1", *deleteOptions.Preconditions.ResourceVersion)
+	})
+
+	t.Run("should return error when delete fails", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t, newConfigMap("first"), newConfigMap("second"))
+		cl.MockDelete = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.DeleteOption) error {
+			return fmt.Errorf("unable to delete")
+		}
+
+		// when
+		results, err := client.NewApplyClient(cl, s).DeleteToolchainObjects(newObjects(t, "first", "second"))
+
+		// then
+		require.EqualError(t, err, "unable to delete the resource of kind: ConfigMap, version: v1, namespace: john-dev, name: first: unable to delete")
+		assert.Empty(t, results)
+	})
+
+	t.Run("wait for deletion", func(t *testing.T) {
+
+		t.Run("should wait until the finalizers are processed", func(t *testing.T) {
+			// given
+			cl := NewFakeClient(t, newConfigMap("first"))
+			// the object is kept (as if it had a finalizer) and removed during the second poll
+			cl.MockDelete = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.DeleteOption) error {
+				return nil
+			}
+			polls := 0
+			cl.MockGet = func(ctx context.Context, key types.NamespacedName, obj runtime.Object) error {
+				polls++
+				if polls == 2 {
+					if err := cl.Client.Delete(ctx, newConfigMap(key.Name)); err != nil {
+						return err
+					}
+				}
+				return cl.Client.Get(ctx, key, obj)
+			}
+
+			// when
+			results, err := client.NewApplyClient(cl, s).DeleteToolchainObjects(newObjects(t, "first"),
+				client.WaitForDeletion(time.Second, 10*time.Millisecond))
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.False(t, results[0].AlreadyDeleted)
+			assert.Equal(t, 2, polls)
+		})
+
+		t.Run("should return error when the timeout is reached", func(t *testing.T) {
+			// given
+			cl := NewFakeClient(t, newConfigMap("first"))
+			cl.MockDelete = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.DeleteOption) error {
+				return nil
+			}
+
+			// when
+			results, err := client.NewApplyClient(cl, s).DeleteToolchainObjects(newObjects(t, "first"),
+				client.WaitForDeletion(50*time.Millisecond, 10*time.Millisecond))
+
+			// then
+			require.EqualError(t, err, "the resources were not deleted within 50ms: ConfigMap john-dev/first")
+			require.Len(t, results, 1)
+		})
+
+		t.Run("should stop waiting when the context is canceled", func(t *testing.T) {
+			// given
+			cl := NewFakeClient(t, newConfigMap("first"))
+			cl.MockDelete = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.DeleteOption) error {
+				return nil
+			}
+			ctx, cancel := context.WithCancel(context.TODO())
+			polls := 0
+			cl.MockGet = func(ctx context.Context, key types.NamespacedName, obj runtime.Object) error {
+				polls++
+				cancel()
+				return cl.Client.Get(ctx, key, obj)
+			}
+
+			// when
+			start := time.Now()
+			results, err := client.NewApplyClient(cl, s).DeleteToolchainObjectsWithContext(ctx, newObjects(t, "first"),
+				client.WaitForDeletion(time.Minute, 10*time.Millisecond))
+
+			// then
+			require.EqualError(t, err, "stopped waiting for the deletion of the resources: ConfigMap john-dev/first: context canceled")
+			require.Len(t, results, 1)
+			assert.Equal(t, 1, polls)
+			assert.Less(t, int64(time.Since(start)), int64(time.Minute))
+		})
+	})
+}
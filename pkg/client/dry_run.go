@@ -0,0 +1,234 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// FieldDiff a difference on a single field between the live object and the desired one
+type FieldDiff struct {
+	// Path the path of the field, eg: `spec.selector.run`
+	Path string
+	// Live the value of the field in the live object (`nil` if the field is not set)
+	Live interface{}
+	// Desired the value of the field in the desired object (`nil` if the field would be removed)
+	Desired interface{}
+}
+
+// DryRunResult the report of what would happen when applying an object
+type DryRunResult struct {
+	GVK       schema.GroupVersionKind
+	Namespace string
+	Name      string
+	Action    ApplyAction
+	// Diff the differences between the live object and the desired one, sorted by path (empty when the object would be created)
+	Diff []FieldDiff
+}
+
+// DryRunApplyObject returns the report of what would happen if the given object was applied, without changing anything on the cluster.
+// The diff is computed from the fields of the desired object (so the fields populated by the server are ignored)
+// and from the fields of the last applied configuration (if any) that are not in the desired object anymore.
+func (p ApplyClient) DryRunApplyObject(obj runtime.Object) (DryRunResult, error) {
+	return p.DryRunApplyObjectWithContext(context.TODO(), obj)
+}
+
+// DryRunApplyObjectWithContext does the same as DryRunApplyObject, but the live object is retrieved with the given context
+func (p ApplyClient) DryRunApplyObjectWithContext(ctx context.Context, obj runtime.Object) (DryRunResult, error) {
+	gvk, err := apiutil.GVKForObject(obj, p.scheme)
+	if err != nil {
+		return DryRunResult{}, errors.Wrapf(err, "unable to get the GVK of the resource '%v'", obj)
+	}
+	metaNew, err := meta.Accessor(obj)
+	if err != nil {
+		return DryRunResult{}, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+	}
+	result := DryRunResult{
+		GVK:       gvk,
+		Namespace: metaNew.GetNamespace(),
+		Name:      metaNew.GetName(),
+	}
+
+	// the live object is retrieved in a new instance, so none of the fields of the desired object are kept in it
+	existing, err := newObject(p.scheme, obj, gvk)
+	if err != nil {
+		return result, errors.Wrapf(err, "unable to create an instance of kind: %s, version: %s", gvk.Kind, gvk.Version)
+	}
+	namespacedName := types.NamespacedName{Namespace: metaNew.GetNamespace(), Name: metaNew.GetName()}
+	if err := p.cl.Get(ctx, namespacedName, existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			result.Action = ApplyActionCreate
+			return result, nil
+		}
+		return result, errors.Wrapf(err, "unable to get the resource '%v'", existing)
+	}
+
+	diff, err := diffObjects(p.scheme, gvk, obj, existing)
+	if err != nil {
+		return result, errors.Wrapf(err, "unable to compute the diff of the resource of kind: %s, version: %s", gvk.Kind, gvk.Version)
+	}
+	result.Diff = diff
+	result.Action = ApplyActionUpdate
+	if len(diff) == 0 {
+		result.Action = ApplyActionUnchanged
+	}
+	return result, nil
+}
+
+// DryRunApplyToolchainObjects returns the report of what would happen if the given objects were applied with `ApplyToolchainObjects`,
+// without changing anything on the cluster. The given objects are not modified.
+func (p ApplyClient) DryRunApplyToolchainObjects(toolchainObjects []ToolchainObject, newLabels map[string]string) ([]DryRunResult, error) {
+	return p.DryRunApplyToolchainObjectsWithContext(context.TODO(), toolchainObjects, newLabels)
+}
+
+// DryRunApplyToolchainObjectsWithContext does the same as DryRunApplyToolchainObjects, but the live objects are retrieved with the given context
+func (p ApplyClient) DryRunApplyToolchainObjectsWithContext(ctx context.Context, toolchainObjects []ToolchainObject, newLabels map[string]string) ([]DryRunResult, error) {
+	results := make([]DryRunResult, 0, len(toolchainObjects))
+	for _, toolchainObject := range toolchainObjects {
+		obj := toolchainObject.GetRuntimeObject().DeepCopyObject()
+		metaObj, err := meta.Accessor(obj)
+		if err != nil {
+			return results, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+		}
+		addLabels(metaObj, newLabels)
+
+		gvk := toolchainObject.GetGvk()
+		result, err := p.DryRunApplyObjectWithContext(ctx, obj)
+		if err != nil {
+			return results, errors.Wrapf(err, "unable to dry-run the resource of kind: %s, version: %s", gvk.Kind, gvk.Version)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// newObject returns a new empty instance of the given object
+func newObject(scheme *runtime.Scheme, obj runtime.Object, gvk schema.GroupVersionKind) (runtime.Object, error) {
+	if _, ok := obj.(runtime.Unstructured); ok {
+		newObj := &unstructured.Unstructured{}
+		newObj.SetGroupVersionKind(gvk)
+		return newObj, nil
+	}
+	return scheme.New(gvk)
+}
+
+// diffObjects computes the field-level differences between the live and the desired objects
+func diffObjects(scheme *runtime.Scheme, gvk schema.GroupVersionKind, desired, live runtime.Object) ([]FieldDiff, error) {
+	desired = desired.DeepCopyObject()
+	// the desired object may have been built without its TypeMeta, which is not a difference with the last applied configuration
+	desired.GetObjectKind().SetGroupVersionKind(gvk)
+	// the immutable fields (eg: `spec.ClusterIP` of a Service) are retained during the update, so they are not a difference
+	if err := RetainFields(scheme, desired, live); err != nil {
+		return nil, err
+	}
+	metaLive, err := meta.Accessor(live)
+	if err != nil {
+		return nil, err
+	}
+	original, err := decodeConfiguration(metaLive.GetAnnotations()[LastAppliedConfigurationAnnotationKey])
+	if err != nil {
+		return nil, err
+	}
+	desiredMap, err := toMapWithoutLastApplied(desired)
+	if err != nil {
+		return nil, err
+	}
+	liveMap, err := toMapWithoutLastApplied(live)
+	if err != nil {
+		return nil, err
+	}
+
+	// the same patch as the one sent by `ApplyObject` is computed, so the fields defaulted by the server in the elements of the lists
+	// with a merge key (eg: the `protocol` of the ports of a Service) are not a difference for the built-in kinds
+	_, patch, _, err := createThreeWayMergePatch(gvk, original, desired, live)
+	if err != nil {
+		return nil, err
+	}
+	patchMap := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &patchMap); err != nil {
+		return nil, err
+	}
+	if metadata, ok := patchMap["metadata"].(map[string]interface{}); ok {
+		removeLastApplied(metadata)
+		if len(metadata) == 0 {
+			delete(patchMap, "metadata")
+		}
+	}
+	diffs := map[string]FieldDiff{}
+	collectFieldDiffs(patchMap, nil, desiredMap, liveMap, diffs)
+	diff := make([]FieldDiff, 0, len(diffs))
+	for _, fieldDiff := range diffs {
+		diff = append(diff, fieldDiff)
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Path < diff[j].Path
+	})
+	return diff, nil
+}
+
+// toMapWithoutLastApplied returns the content of the given object as a map, without the status,
+// the metadata fields populated by the server and the last applied configuration annotation, which are not relevant in the diff.
+func toMapWithoutLastApplied(obj runtime.Object) (map[string]interface{}, error) {
+	content, err := marshalCleanJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, err
+	}
+	if metadata, ok := m["metadata"].(map[string]interface{}); ok {
+		removeLastApplied(metadata)
+	}
+	return m, nil
+}
+
+// removeLastApplied removes the last applied configuration annotation from the given metadata
+func removeLastApplied(metadata map[string]interface{}) {
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		delete(annotations, LastAppliedConfigurationAnnotationKey)
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
+
+// collectFieldDiffs walks through the given patch and collects the differences of its leaf fields, indexed by their path.
+// The directives of the strategic merge patch (eg: `$setElementOrder/ports` or `$deleteFromPrimitiveList/finalizers`) are reported
+// as a difference on the list they apply to, while the other directives (eg: `$retainKeys`) are ignored.
+func collectFieldDiffs(patch map[string]interface{}, path []string, desired, live map[string]interface{}, diffs map[string]FieldDiff) {
+	for key := range patch {
+		field := key
+		if strings.HasPrefix(key, "$") {
+			separator := strings.Index(key, "/")
+			if separator < 0 {
+				continue
+			}
+			field = key[separator+1:]
+		}
+		fieldPath := append(append([]string{}, path...), field)
+		if nested, ok := patch[key].(map[string]interface{}); ok && field == key {
+			desiredNested, desiredIsMap := desired[field].(map[string]interface{})
+			liveNested, liveIsMap := live[field].(map[string]interface{})
+			if desiredIsMap && liveIsMap {
+				collectFieldDiffs(nested, fieldPath, desiredNested, liveNested, diffs)
+				continue
+			}
+		}
+		diffs[strings.Join(fieldPath, ".")] = FieldDiff{
+			Path:    strings.Join(fieldPath, "."),
+			Live:    live[field],
+			Desired: desired[field],
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+	"github.com/codeready-toolchain/toolchain-common/pkg/template"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDryRunApplyObject(t *testing.T) {
+	// given
+	s := addToScheme(t)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registration-service",
+			Namespace: "toolchain-host-operator",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				"run": "registration-service",
+			},
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registration-service",
+			Namespace: "toolchain-host-operator",
+		},
+		Data: map[string]string{
+			"first-param":  "first-value",
+			"second-param": "second-value",
+		},
+	}
+
+	t.Run("should report create when object is missing", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+
+		// when
+		result, err := cl.DryRunApplyObject(service.DeepCopy())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, client.ApplyActionCreate, result.Action)
+		assert.Equal(t, "Service", result.GVK.Kind)
+		assert.Equal(t, "toolchain-host-operator", result.Namespace)
+		assert.Equal(t, "registration-service", result.Name)
+		assert.Empty(t, result.Diff)
+		err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}, &corev1.Service{})
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("should report unchanged when object is same", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		existing := service.DeepCopy()
+		existing.Spec.ClusterIP = "10.2.3.4"
+		_, err := cl.ApplyObject(existing)
+		require.NoError(t, err)
+		// the server populates other fields
+		existing.Spec.SessionAffinity = corev1.ServiceAffinityNone
+		err = cli.Update(context.TODO(), existing)
+		require.NoError(t, err)
+
+		// when
+		result, err := cl.DryRunApplyObject(service.DeepCopy())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, client.ApplyActionUnchanged, result.Action)
+		assert.Empty(t, result.Diff)
+	})
+
+	t.Run("should report update with diff when object is different", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		_, err := cl.ApplyObject(service.DeepCopy())
+		require.NoError(t, err)
+		modified := service.DeepCopy()
+		modified.Spec.Selector["run"] = "all-services"
+		modified.Labels = map[string]string{"provider": "codeready-toolchain"}
+
+		// when
+		result, err := cl.DryRunApplyObject(modified)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, client.ApplyActionUpdate, result.Action)
+		assert.Equal(t, []client.FieldDiff{
+			{
+				Path:    "metadata.labels",
+				Live:    nil,
+				Desired: map[string]interface{}{"provider": "codeready-toolchain"},
+			},
+			{
+				Path:    "spec.selector.run",
+				Live:    "registration-service",
+				Desired: "all-services",
+			},
+		}, result.Diff)
+		// verify that the object was not updated
+		actual := &corev1.Service{}
+		err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}, actual)
+		require.NoError(t, err)
+		assert.Equal(t, "registration-service", actual.Spec.Selector["run"])
+	})
+
+	t.Run("should report removed fields of last applied configuration", func(t *testing.T) {
+		// given
+		cl, _ := newClient(t, s)
+		_, err := cl.ApplyObject(cm.DeepCopy())
+		require.NoError(t, err)
+		modified := cm.DeepCopy()
+		delete(modified.Data, "second-param")
+
+		// when
+		result, err := cl.DryRunApplyObject(modified)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, client.ApplyActionUpdate, result.Action)
+		assert.Equal(t, []client.FieldDiff{
+			{
+				Path:    "data.second-param",
+				Live:    "second-value",
+				Desired: nil,
+			},
+		}, result.Diff)
+	})
+
+	t.Run("should report unchanged when object is same but without its TypeMeta", func(t *testing.T) {
+		// given
+		cl, _ := newClient(t, s)
+		withTypeMeta := cm.DeepCopy()
+		withTypeMeta.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+		_, err := cl.ApplyObject(withTypeMeta)
+		require.NoError(t, err)
+
+		// when
+		result, err := cl.DryRunApplyObject(cm.DeepCopy())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, client.ApplyActionUnchanged, result.Action)
+		assert.Empty(t, result.Diff)
+	})
+
+	t.Run("should report unchanged when the server defaulted fields in the elements of a list", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		withPorts := service.DeepCopy()
+		withPorts.Spec.Ports = []corev1.ServicePort{{Port: 80}}
+		_, err := cl.ApplyObject(withPorts.DeepCopy())
+		require.NoError(t, err)
+		// the server defaults the protocol of the port
+		existing := &corev1.Service{}
+		err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}, existing)
+		require.NoError(t, err)
+		existing.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+		err = cli.Update(context.TODO(), existing)
+		require.NoError(t, err)
+
+		t.Run("unchanged", func(t *testing.T) {
+			// when
+			result, err := cl.DryRunApplyObject(withPorts.DeepCopy())
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, client.ApplyActionUnchanged, result.Action)
+			assert.Empty(t, result.Diff)
+		})
+
+		t.Run("updated", func(t *testing.T) {
+			// given
+			modified := withPorts.DeepCopy()
+			modified.Spec.Ports[0].Port = 8080
+
+			// when
+			result, err := cl.DryRunApplyObject(modified)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, client.ApplyActionUpdate, result.Action)
+			require.Len(t, result.Diff, 1)
+			assert.Equal(t, "spec.ports", result.Diff[0].Path)
+		})
+	})
+
+	t.Run("should use the given context to retrieve the object", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		cli.MockGet = func(ctx context.Context, key runtimeclient.ObjectKey, obj runtime.Object) error {
+			return ctx.Err()
+		}
+
+		// when
+		_, err := cl.DryRunApplyObjectWithContext(ctx, service.DeepCopy())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "context canceled")
+	})
+
+	t.Run("should fail when object cannot be retrieved", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		cli.MockGet = func(ctx context.Context, key runtimeclient.ObjectKey, obj runtime.Object) error {
+			return fmt.Errorf("unable to get")
+		}
+
+		// when
+		_, err := cl.DryRunApplyObject(service.DeepCopy())
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to get the resource")
+	})
+}
+
+func TestDryRunApplyToolchainObjects(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	codecFactory := serializer.NewCodecFactory(s)
+	decoder := codecFactory.UniversalDeserializer()
+	user := getNameWithTimestamp("user")
+	values := map[string]string{
+		"USERNAME": user,
+		"COMMIT":   getNameWithTimestamp("sha"),
+	}
+	p := template.NewProcessor(s)
+	tmpl, err := DecodeTemplate(decoder,
+		CreateTemplate(WithObjects(Namespace, RoleBinding), WithParams(UsernameParam, CommitParam)))
+	require.NoError(t, err)
+
+	t.Run("should report create for all objects", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t)
+		objs, err := p.Process(tmpl, values)
+		require.NoError(t, err)
+
+		// when
+		results, err := client.NewApplyClient(cl, s).DryRunApplyToolchainObjects(objs, newLabels("basic", "john", "dev"))
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, client.ApplyActionCreate, results[0].Action)
+		assert.Equal(t, "Namespace", results[0].GVK.Kind)
+		assert.Equal(t, client.ApplyActionCreate, results[1].Action)
+		assert.Equal(t, "RoleBinding", results[1].GVK.Kind)
+		// the given objects are not modified
+		assert.NotContains(t, objs[0].GetLabels(), "toolchain.dev.openshift.com/tier")
+	})
+
+	t.Run("should report unchanged and updated objects", func(t *testing.T) {
+		// given
+		cl := NewFakeClient(t)
+		objs, err := p.Process(tmpl, values)
+		require.NoError(t, err)
+		_, err = client.NewApplyClient(cl, s).ApplyToolchainObjects(objs, newLabels("basic", "john", "dev"))
+		require.NoError(t, err)
+
+		t.Run("unchanged", func(t *testing.T) {
+			// given
+			objs, err := p.Process(tmpl, values)
+			require.NoError(t, err)
+
+			// when
+			results, err := client.NewApplyClient(cl, s).DryRunApplyToolchainObjects(objs, newLabels("basic", "john", "dev"))
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			assert.Equal(t, client.ApplyActionUnchanged, results[0].Action)
+			assert.Equal(t, client.ApplyActionUnchanged, results[1].Action)
+		})
+
+		t.Run("updated", func(t *testing.T) {
+			// given
+			objs, err := p.Process(tmpl, values)
+			require.NoError(t, err)
+
+			// when
+			results, err := client.NewApplyClient(cl, s).DryRunApplyToolchainObjects(objs, newLabels("advanced", "john", "dev"))
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			for _, result := range results {
+				assert.Equal(t, client.ApplyActionUpdate, result.Action)
+				assert.Equal(t, []client.FieldDiff{
+					{
+						Path:    "metadata.labels.toolchain.dev.openshift.com/tier",
+						Live:    "basic",
+						Desired: "advanced",
+					},
+				}, result.Diff)
+			}
+		})
+	})
+}
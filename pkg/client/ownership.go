@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// OwnerNameLabelKey the label key for the name of the owner of a resource, when the owner is in another namespace or another cluster
+	OwnerNameLabelKey = "toolchain.dev.openshift.com/owner-name"
+	// OwnerNamespaceLabelKey the label key for the namespace of the owner of a resource (empty for a cluster-scoped owner)
+	OwnerNamespaceLabelKey = "toolchain.dev.openshift.com/owner-namespace"
+	// OwnerKindLabelKey the label key for the kind of the owner of a resource
+	OwnerKindLabelKey = "toolchain.dev.openshift.com/owner-kind"
+	// OwnerClusterLabelKey the label key for the name of the cluster of the owner of a resource (empty for the same cluster)
+	OwnerClusterLabelKey = "toolchain.dev.openshift.com/owner-cluster"
+	// OwnerAPIVersionAnnotationKey the annotation key for the API version of the owner of a resource
+	OwnerAPIVersionAnnotationKey = "toolchain.dev.openshift.com/owner-api-version"
+	// OwnerUIDAnnotationKey the annotation key for the UID of the owner of a resource
+	OwnerUIDAnnotationKey = "toolchain.dev.openshift.com/owner-uid"
+)
+
+// setOwnerReference sets the owner reference of the configured owner (if any) into the given object,
+// either as a controller reference or as a regular owner reference
+func (p ApplyClient) setOwnerReference(metaNew v1.Object, config applyObjectConfiguration) error {
+	if config.owner == nil {
+		return nil
+	}
+	if config.controllerOwner {
+		if err := controllerutil.SetControllerReference(config.owner, metaNew, p.scheme); err != nil {
+			return errors.Wrap(err, "unable to set controller references")
+		}
+		return nil
+	}
+	if err := controllerutil.SetOwnerReference(config.owner, metaNew, p.scheme); err != nil {
+		return errors.Wrap(err, "unable to set owner references")
+	}
+	return nil
+}
+
+// setCrossClusterOwner sets the labels and annotations which refer to the given owner into the given object.
+// Contrary to the owner references, they can refer to an owner in another namespace or in another cluster,
+// but the object is not garbage collected when the owner is deleted.
+func (p ApplyClient) setCrossClusterOwner(metaNew v1.Object, owner v1.Object, clusterName string) error {
+	gvk, err := p.ownerGVK(owner)
+	if err != nil {
+		return err
+	}
+	labels := metaNew.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for key, value := range ownerLabels(gvk, owner, clusterName) {
+		labels[key] = value
+	}
+	metaNew.SetLabels(labels)
+
+	annotations := metaNew.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[OwnerAPIVersionAnnotationKey] = gvk.GroupVersion().String()
+	annotations[OwnerUIDAnnotationKey] = string(owner.GetUID())
+	metaNew.SetAnnotations(annotations)
+	return nil
+}
+
+// ownerLabels returns the labels which refer to the given owner in the given cluster
+func ownerLabels(gvk schema.GroupVersionKind, owner v1.Object, clusterName string) map[string]string {
+	return map[string]string{
+		OwnerNameLabelKey:      owner.GetName(),
+		OwnerNamespaceLabelKey: owner.GetNamespace(),
+		OwnerKindLabelKey:      gvk.Kind,
+		OwnerClusterLabelKey:   clusterName,
+	}
+}
+
+func (p ApplyClient) ownerGVK(owner v1.Object) (schema.GroupVersionKind, error) {
+	ro, ok := owner.(runtime.Object)
+	if !ok {
+		return schema.GroupVersionKind{}, fmt.Errorf("%T is not a runtime.Object, cannot get the GVK of the owner", owner)
+	}
+	gvk, err := apiutil.GVKForObject(ro, p.scheme)
+	if err != nil {
+		return schema.GroupVersionKind{}, errors.Wrapf(err, "unable to get the GVK of the owner '%s'", owner.GetName())
+	}
+	return gvk, nil
+}
+
+// fixOwnerReferences sets the owner references of the existing object into the new object, along with the reference to the
+// configured owner. Returns `true` if the owner references of the existing object need to be updated.
+func (p ApplyClient) fixOwnerReferences(metaNew, metaExisting v1.Object, config applyObjectConfiguration) (bool, error) {
+	existingReferences := metaExisting.GetOwnerReferences()
+	metaNew.SetOwnerReferences(append([]v1.OwnerReference{}, existingReferences...))
+	if err := p.setOwnerReference(metaNew, config); err != nil {
+		return false, err
+	}
+	return !equality.Semantic.DeepEqual(existingReferences, metaNew.GetOwnerReferences()), nil
+}
+
+// ListOwnedObjects returns the objects of the given GVKs which are owned by the given owner, either via an owner reference
+// (looked up in the namespace of the owner, or in all namespaces if the owner is cluster-scoped),
+// or via the owner labels set with the `SetCrossClusterOwner` option with the given cluster name
+func (p ApplyClient) ListOwnedObjects(owner v1.Object, clusterName string, gvks []schema.GroupVersionKind) ([]ToolchainObject, error) {
+	return p.ListOwnedObjectsWithContext(context.TODO(), owner, clusterName, gvks)
+}
+
+// ListOwnedObjectsWithContext does the same as ListOwnedObjects, but all the calls to the API server use the given context
+func (p ApplyClient) ListOwnedObjectsWithContext(ctx context.Context, owner v1.Object, clusterName string, gvks []schema.GroupVersionKind) ([]ToolchainObject, error) {
+	ownerGVK, err := p.ownerGVK(owner)
+	if err != nil {
+		return nil, err
+	}
+	var owned []ToolchainObject
+	for _, gvk := range gvks {
+		byReference, err := p.listObjects(ctx, gvk, client.InNamespace(owner.GetNamespace()))
+		if err != nil {
+			return owned, err
+		}
+		for _, obj := range byReference {
+			if isOwnedBy(obj, owner) {
+				owned = append(owned, obj)
+			}
+		}
+		byLabels, err := p.listObjects(ctx, gvk, client.MatchingLabels(ownerLabels(ownerGVK, owner, clusterName)))
+		if err != nil {
+			return owned, err
+		}
+		for _, obj := range byLabels {
+			if !isDesired(owned, obj) {
+				owned = append(owned, obj)
+			}
+		}
+	}
+	return owned, nil
+}
+
+// listObjects returns the objects of the given GVK that match the given options
+func (p ApplyClient) listObjects(ctx context.Context, gvk schema.GroupVersionKind, options ...client.ListOption) ([]ToolchainObject, error) {
+	list, err := p.newList(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.cl.List(ctx, list, options...); err != nil {
+		return nil, errors.Wrapf(err, "unable to list the resources of kind: %s, version: %s", gvk.Kind, gvk.Version)
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to extract the resources of kind: %s, version: %s", gvk.Kind, gvk.Version)
+	}
+	objs := make([]ToolchainObject, len(items))
+	for i, item := range items {
+		item.GetObjectKind().SetGroupVersionKind(gvk)
+		obj, err := NewToolchainObject(item)
+		if err != nil {
+			return nil, err
+		}
+		objs[i] = obj
+	}
+	return objs, nil
+}
+
+// isOwnedBy checks if the given object has an owner reference to the given owner
+func isOwnedBy(obj ToolchainObject, owner v1.Object) bool {
+	for _, reference := range obj.GetOwnerReferences() {
+		if reference.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
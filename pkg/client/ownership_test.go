@@ -0,0 +1,220 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestApplyObjectOwnership(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	namespacedName := types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}
+	newConfigMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      namespacedName.Name,
+				Namespace: namespacedName.Namespace,
+			},
+			Data: map[string]string{
+				"first-param": "first-value",
+			},
+		}
+	}
+	newOwner := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespacedName.Namespace,
+				UID:       types.UID(name + "-uid"),
+			},
+		}
+	}
+
+	t.Run("should set non-controller owner reference", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+
+		// when
+		createdOrChanged, err := cl.ApplyObject(newConfigMap(), client.SetNonControllerOwner(newOwner("first")))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, createdOrChanged)
+		configMap := &corev1.ConfigMap{}
+		err = cli.Get(context.TODO(), namespacedName, configMap)
+		require.NoError(t, err)
+		require.Len(t, configMap.OwnerReferences, 1)
+		assert.Equal(t, "first", configMap.OwnerReferences[0].Name)
+		assert.Nil(t, configMap.OwnerReferences[0].Controller)
+
+		t.Run("should add another owner reference when updating", func(t *testing.T) {
+			// when
+			createdOrChanged, err := cl.ApplyObject(newConfigMap(), client.SetNonControllerOwner(newOwner("second")), client.UpdateOwnerReferences(true))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, createdOrChanged)
+			configMap := &corev1.ConfigMap{}
+			err = cli.Get(context.TODO(), namespacedName, configMap)
+			require.NoError(t, err)
+			require.Len(t, configMap.OwnerReferences, 2)
+			assert.Equal(t, "first", configMap.OwnerReferences[0].Name)
+			assert.Equal(t, "second", configMap.OwnerReferences[1].Name)
+		})
+	})
+
+	t.Run("should set controller reference on existing object", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		_, err := cl.ApplyObject(newConfigMap())
+		require.NoError(t, err)
+
+		t.Run("should not set it without the option", func(t *testing.T) {
+			// when
+			createdOrChanged, err := cl.ApplyObject(newConfigMap(), client.SetOwner(newOwner("first")))
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, createdOrChanged)
+			configMap := &corev1.ConfigMap{}
+			err = cli.Get(context.TODO(), namespacedName, configMap)
+			require.NoError(t, err)
+			assert.Empty(t, configMap.OwnerReferences)
+		})
+
+		t.Run("should set it with the option", func(t *testing.T) {
+			// when
+			createdOrChanged, err := cl.ApplyObject(newConfigMap(), client.SetOwner(newOwner("first")), client.UpdateOwnerReferences(true))
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, createdOrChanged)
+			configMap := &corev1.ConfigMap{}
+			err = cli.Get(context.TODO(), namespacedName, configMap)
+			require.NoError(t, err)
+			require.Len(t, configMap.OwnerReferences, 1)
+			assert.Equal(t, "first", configMap.OwnerReferences[0].Name)
+			assert.True(t, *configMap.OwnerReferences[0].Controller)
+		})
+
+		t.Run("should not update when it is already set", func(t *testing.T) {
+			// when
+			createdOrChanged, err := cl.ApplyObject(newConfigMap(), client.SetOwner(newOwner("first")), client.UpdateOwnerReferences(true))
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, createdOrChanged)
+		})
+
+		t.Run("should fail when another controller is set", func(t *testing.T) {
+			// when
+			_, err := cl.ApplyObject(newConfigMap(), client.SetOwner(newOwner("second")), client.UpdateOwnerReferences(true))
+
+			// then
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "unable to set controller references")
+		})
+	})
+
+	t.Run("should set cross-cluster owner", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+
+		// when
+		createdOrChanged, err := cl.ApplyObject(newConfigMap(), client.SetCrossClusterOwner(newOwner("first"), "member-cluster"))
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, createdOrChanged)
+		configMap := &corev1.ConfigMap{}
+		err = cli.Get(context.TODO(), namespacedName, configMap)
+		require.NoError(t, err)
+		assert.Empty(t, configMap.OwnerReferences)
+		assert.Equal(t, map[string]string{
+			client.OwnerNameLabelKey:      "first",
+			client.OwnerNamespaceLabelKey: "toolchain-host-operator",
+			client.OwnerKindLabelKey:      "Deployment",
+			client.OwnerClusterLabelKey:   "member-cluster",
+		}, configMap.Labels)
+		assert.Equal(t, "apps/v1", configMap.Annotations[client.OwnerAPIVersionAnnotationKey])
+		assert.Equal(t, "first-uid", configMap.Annotations[client.OwnerUIDAnnotationKey])
+	})
+}
+
+func TestListOwnedObjects(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	configMapGVK := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+	owner := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registration-service",
+			Namespace: "toolchain-host-operator",
+			UID:       types.UID("registration-service-uid"),
+		},
+	}
+	newConfigMap := func(name, namespace string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+	}
+	cl, _ := newClient(t, s)
+	_, err := cl.ApplyObject(newConfigMap("by-reference", "toolchain-host-operator"), client.SetNonControllerOwner(owner))
+	require.NoError(t, err)
+	_, err = cl.ApplyObject(newConfigMap("by-labels", "john-dev"), client.SetCrossClusterOwner(owner, "host-cluster"))
+	require.NoError(t, err)
+	_, err = cl.ApplyObject(newConfigMap("by-labels-in-other-cluster", "john-dev"), client.SetCrossClusterOwner(owner, "other-cluster"))
+	require.NoError(t, err)
+	_, err = cl.ApplyObject(newConfigMap("not-owned", "toolchain-host-operator"))
+	require.NoError(t, err)
+
+	// when
+	owned, err := cl.ListOwnedObjects(owner, "host-cluster", []schema.GroupVersionKind{configMapGVK})
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, owned, 2)
+	assert.Equal(t, "by-reference", owned[0].GetName())
+	assert.Equal(t, configMapGVK, owned[0].GetGvk())
+	assert.Equal(t, "by-labels", owned[1].GetName())
+	assert.Equal(t, "john-dev", owned[1].GetNamespace())
+}
+
+func TestListOwnedObjectsWithContext(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	owner := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registration-service",
+			Namespace: "toolchain-host-operator",
+			UID:       types.UID("registration-service-uid"),
+		},
+	}
+	cl, fakeClient := newClient(t, s)
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	fakeClient.MockList = func(ctx context.Context, list runtime.Object, opts ...runtimeclient.ListOption) error {
+		return ctx.Err()
+	}
+
+	// when
+	owned, err := cl.ListOwnedObjectsWithContext(ctx, owner, "host-cluster", []schema.GroupVersionKind{corev1.SchemeGroupVersion.WithKind("ConfigMap")})
+
+	// then
+	require.EqualError(t, err, "unable to list the resources of kind: ConfigMap, version: v1: context canceled")
+	assert.Empty(t, owned)
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	kubernetesscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// builtInScheme contains only the Kubernetes built-in types, ie, the types that support the strategic merge patch.
+// The `scheme.Scheme` of client-go cannot be used for this purpose, since the operators register their own types (CRDs) in it.
+var builtInScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(kubernetesscheme.AddToScheme(builtInScheme))
+}
+
+// createThreeWayMergePatch computes the patch to send to the server based on the last applied (original) configuration,
+// the desired (modified) and the live (current) objects. A strategic merge patch is computed for Kubernetes built-in types,
+// while a JSON merge patch is computed for all other types (CRDs and unstructured objects of unknown kinds).
+// The returned boolean is `false` if the patch is empty, ie, there is nothing to update.
+func createThreeWayMergePatch(gvk schema.GroupVersionKind, original []byte, modified, current runtime.Object) (types.PatchType, []byte, bool, error) {
+	originalJSON, err := cleanJSON(original)
+	if err != nil {
+		return "", nil, false, errors.Wrap(err, "unable to read the last applied configuration")
+	}
+	modifiedJSON, err := marshalCleanJSON(modified)
+	if err != nil {
+		return "", nil, false, err
+	}
+	currentJSON, err := marshalCleanJSON(current)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	var patchType types.PatchType
+	var patch []byte
+	versionedObject, err := builtInScheme.New(gvk)
+	switch {
+	case err == nil:
+		patchType = types.StrategicMergePatchType
+		lookupPatchMeta, err := strategicpatch.NewPatchMetaFromStruct(versionedObject)
+		if err != nil {
+			return "", nil, false, errors.Wrapf(err, "unable to get the patch metadata of kind: %s", gvk.Kind)
+		}
+		patch, err = strategicpatch.CreateThreeWayMergePatch(originalJSON, modifiedJSON, currentJSON, lookupPatchMeta, true)
+		if err != nil {
+			return "", nil, false, errors.Wrap(err, "unable to create the strategic merge patch")
+		}
+	case runtime.IsNotRegisteredError(err):
+		patchType = types.MergePatchType
+		patch, err = jsonmergepatch.CreateThreeWayJSONMergePatch(originalJSON, modifiedJSON, currentJSON)
+		if err != nil {
+			return "", nil, false, errors.Wrap(err, "unable to create the JSON merge patch")
+		}
+	default:
+		return "", nil, false, errors.Wrapf(err, "unable to create an instance of kind: %s", gvk.Kind)
+	}
+	return patchType, patch, string(patch) != "{}", nil
+}
+
+func marshalCleanJSON(obj runtime.Object) ([]byte, error) {
+	content, err := marshalObjectContent(obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to marshal the object %v", obj)
+	}
+	return cleanJSON(content)
+}
+
+// cleanJSON removes the status and the metadata fields that are populated by the server from the given JSON content
+func cleanJSON(content []byte) ([]byte, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "status")
+	if metadata, ok := m["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"creationTimestamp", "resourceVersion", "generation", "uid", "selfLink", "managedFields"} {
+			delete(metadata, field)
+		}
+	}
+	return json.Marshal(m)
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type pruneConfiguration struct {
+	dryRun bool
+}
+
+func newPruneConfiguration(options ...PruneOption) pruneConfiguration {
+	config := pruneConfiguration{
+		dryRun: false,
+	}
+	for _, apply := range options {
+		apply(&config)
+	}
+	return config
+}
+
+// PruneOption an option when pruning the stale resources
+type PruneOption func(*pruneConfiguration)
+
+// PruneDryRun only returns the resources that would be deleted, without deleting them (default: `false`)
+func PruneDryRun(dryRun bool) PruneOption {
+	return func(config *pruneConfiguration) {
+		config.dryRun = dryRun
+	}
+}
+
+// ApplyToolchainObjectsAndPrune applies the objects with the given labels (see `ApplyToolchainObjects`) and then deletes the stale objects,
+// ie, the objects of the allowed GVKs that have the same labels but that are not part of the given objects anymore (see `PruneToolchainObjects`).
+// Returns `true, nil` if at least one of the objects was created, modified or deleted, `false, nil` if nothing changed,
+// and `false, err` if an error occurred
+func (p ApplyClient) ApplyToolchainObjectsAndPrune(toolchainObjects []ToolchainObject, newLabels map[string]string, allowedGVKs []schema.GroupVersionKind, options ...PruneOption) (bool, error) {
+	return p.ApplyToolchainObjectsAndPruneWithContext(context.TODO(), toolchainObjects, newLabels, allowedGVKs, options...)
+}
+
+// ApplyToolchainObjectsAndPruneWithContext does the same as ApplyToolchainObjectsAndPrune, but all the calls to the API server use the given context
+func (p ApplyClient) ApplyToolchainObjectsAndPruneWithContext(ctx context.Context, toolchainObjects []ToolchainObject, newLabels map[string]string, allowedGVKs []schema.GroupVersionKind, options ...PruneOption) (bool, error) {
+	createdOrUpdated, err := p.ApplyToolchainObjectsWithContext(ctx, toolchainObjects, newLabels)
+	if err != nil {
+		return false, err
+	}
+	pruned, err := p.PruneToolchainObjectsWithContext(ctx, toolchainObjects, newLabels, allowedGVKs, options...)
+	if err != nil {
+		return false, err
+	}
+	config := newPruneConfiguration(options...)
+	return createdOrUpdated || (len(pruned) > 0 && !config.dryRun), nil
+}
+
+// PruneToolchainObjects deletes the objects that have all the given labels, but that are not part of the desired objects.
+// Only the objects whose GVK is in the given allow-list are looked up and deleted.
+// Returns the objects that were deleted (or that would be deleted when the `PruneDryRun` option is set)
+func (p ApplyClient) PruneToolchainObjects(desiredObjects []ToolchainObject, labels map[string]string, allowedGVKs []schema.GroupVersionKind, options ...PruneOption) ([]ToolchainObject, error) {
+	return p.PruneToolchainObjectsWithContext(context.TODO(), desiredObjects, labels, allowedGVKs, options...)
+}
+
+// PruneToolchainObjectsWithContext does the same as PruneToolchainObjects, but all the calls to the API server use the given context.
+// The logger carried by the context (see ContextWithLogger) is used instead of the default one.
+func (p ApplyClient) PruneToolchainObjectsWithContext(ctx context.Context, desiredObjects []ToolchainObject, labels map[string]string, allowedGVKs []schema.GroupVersionKind, options ...PruneOption) ([]ToolchainObject, error) {
+	if len(labels) == 0 {
+		return nil, errors.New("unable to prune the resources without any label to select them")
+	}
+	config := newPruneConfiguration(options...)
+	logger := loggerFromContext(ctx)
+
+	var pruned []ToolchainObject
+	for _, gvk := range allowedGVKs {
+		existingObjects, err := p.listObjects(ctx, gvk, client.MatchingLabels(labels))
+		if err != nil {
+			return pruned, err
+		}
+		for _, existingObject := range existingObjects {
+			if isDesired(desiredObjects, existingObject) {
+				continue
+			}
+			if !config.dryRun {
+				logger.Info("deleting stale resource", "kind", gvk.Kind, "namespace", existingObject.GetNamespace(), "name", existingObject.GetName())
+				if err := p.cl.Delete(ctx, existingObject.GetRuntimeObject()); err != nil && !apierrors.IsNotFound(err) {
+					return pruned, errors.Wrapf(err, "unable to delete the resource of kind: %s, version: %s", gvk.Kind, gvk.Version)
+				}
+			}
+			pruned = append(pruned, existingObject)
+		}
+	}
+	return pruned, nil
+}
+
+// newList returns a new list for the given GVK. It's a typed list if the GVK is known by the scheme, an unstructured list otherwise
+func (p ApplyClient) newList(gvk schema.GroupVersionKind) (runtime.Object, error) {
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+	list, err := p.scheme.New(listGVK)
+	if err == nil {
+		return list, nil
+	}
+	if !runtime.IsNotRegisteredError(err) {
+		return nil, errors.Wrapf(err, "unable to create a list of kind: %s, version: %s", gvk.Kind, gvk.Version)
+	}
+	unstructuredList := &unstructured.UnstructuredList{}
+	unstructuredList.SetGroupVersionKind(listGVK)
+	return unstructuredList, nil
+}
+
+// isDesired checks if the given existing object is part of the desired objects, ie, if one of them has the same key (see HasSameKey)
+func isDesired(desiredObjects []ToolchainObject, existing ToolchainObject) bool {
+	for _, desired := range desiredObjects {
+		if desired.HasSameKey(existing) {
+			return true
+		}
+	}
+	return false
+}
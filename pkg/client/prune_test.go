@@ -0,0 +1,205 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+	"github.com/codeready-toolchain/toolchain-common/pkg/template"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	authv1 "github.com/openshift/api/authorization/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPruneToolchainObjects(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	codecFactory := serializer.NewCodecFactory(s)
+	decoder := codecFactory.UniversalDeserializer()
+	user := getNameWithTimestamp("user")
+	values := map[string]string{
+		"USERNAME": user,
+		"COMMIT":   getNameWithTimestamp("sha"),
+	}
+	p := template.NewProcessor(s)
+	labels := newLabels("basic", "john", "dev")
+	roleBindingGVK := authv1.GroupVersion.WithKind("RoleBinding")
+	namespaceGVK := corev1.SchemeGroupVersion.WithKind("Namespace")
+	roleBindingName := types.NamespacedName{Namespace: user, Name: fmt.Sprintf("%s-edit", user)}
+
+	// creates the namespace and the role binding, and returns the new desired objects (namespace only)
+	setup := func(t *testing.T) (*FakeClient, []client.ToolchainObject) {
+		ns := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   user,
+				Labels: expectedLabels(labels, values["COMMIT"]),
+			},
+		}
+		rb := &authv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      roleBindingName.Name,
+				Namespace: roleBindingName.Namespace,
+				Labels:    expectedLabels(labels, ""),
+			},
+		}
+		cl := NewFakeClient(t, ns, rb)
+
+		tmpl, err := DecodeTemplate(decoder,
+			CreateTemplate(WithObjects(Namespace), WithParams(UsernameParam, CommitParam)))
+		require.NoError(t, err)
+		objs, err := p.Process(tmpl, values)
+		require.NoError(t, err)
+		return cl, objs
+	}
+
+	t.Run("should delete the object that is not desired anymore", func(t *testing.T) {
+		// given
+		cl, objs := setup(t)
+
+		// when
+		pruned, err := client.NewApplyClient(cl, s).PruneToolchainObjects(objs, labels, []schema.GroupVersionKind{namespaceGVK, roleBindingGVK})
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, pruned, 1)
+		assert.Equal(t, roleBindingGVK, pruned[0].GetGvk())
+		assert.Equal(t, roleBindingName.Name, pruned[0].GetName())
+		err = cl.Get(context.TODO(), roleBindingName, &authv1.RoleBinding{})
+		assert.True(t, apierrors.IsNotFound(err))
+		assertNamespaceExists(t, cl, user, labels, values["COMMIT"])
+	})
+
+	t.Run("should not delete the object when using dry-run", func(t *testing.T) {
+		// given
+		cl, objs := setup(t)
+
+		// when
+		pruned, err := client.NewApplyClient(cl, s).PruneToolchainObjects(objs, labels, []schema.GroupVersionKind{roleBindingGVK}, client.PruneDryRun(true))
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, pruned, 1)
+		assert.Equal(t, roleBindingName.Name, pruned[0].GetName())
+		assertRoleBindingExists(t, cl, user, labels)
+	})
+
+	t.Run("should not delete the object when its GVK is not in the allow-list", func(t *testing.T) {
+		// given
+		cl, objs := setup(t)
+
+		// when
+		pruned, err := client.NewApplyClient(cl, s).PruneToolchainObjects(objs, labels, []schema.GroupVersionKind{namespaceGVK})
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, pruned)
+		assertRoleBindingExists(t, cl, user, labels)
+	})
+
+	t.Run("should not delete the object when it does not have the labels", func(t *testing.T) {
+		// given
+		cl, objs := setup(t)
+
+		// when
+		pruned, err := client.NewApplyClient(cl, s).PruneToolchainObjects(objs, newLabels("advanced", "john", "dev"), []schema.GroupVersionKind{roleBindingGVK})
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, pruned)
+		assertRoleBindingExists(t, cl, user, labels)
+	})
+
+	t.Run("should apply and prune", func(t *testing.T) {
+		// given
+		cl, objs := setup(t)
+
+		// when
+		changed, err := client.NewApplyClient(cl, s).ApplyToolchainObjectsAndPrune(objs, labels, []schema.GroupVersionKind{roleBindingGVK})
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, changed)
+		err = cl.Get(context.TODO(), roleBindingName, &authv1.RoleBinding{})
+		assert.True(t, apierrors.IsNotFound(err))
+
+		t.Run("nothing changed when applied again", func(t *testing.T) {
+			// when
+			changed, err := client.NewApplyClient(cl, s).ApplyToolchainObjectsAndPrune(objs, labels, []schema.GroupVersionKind{roleBindingGVK})
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("should fail when no labels are given", func(t *testing.T) {
+			// given
+			cl, objs := setup(t)
+
+			// when
+			_, err := client.NewApplyClient(cl, s).PruneToolchainObjects(objs, nil, []schema.GroupVersionKind{roleBindingGVK})
+
+			// then
+			require.EqualError(t, err, "unable to prune the resources without any label to select them")
+			assertRoleBindingExists(t, cl, user, labels)
+		})
+
+		t.Run("should fail when objects cannot be listed", func(t *testing.T) {
+			// given
+			cl, objs := setup(t)
+			cl.MockList = func(ctx context.Context, list runtime.Object, opts ...runtimeclient.ListOption) error {
+				return fmt.Errorf("unable to list")
+			}
+
+			// when
+			_, err := client.NewApplyClient(cl, s).PruneToolchainObjects(objs, labels, []schema.GroupVersionKind{roleBindingGVK})
+
+			// then
+			require.EqualError(t, err, "unable to list the resources of kind: RoleBinding, version: v1: unable to list")
+		})
+
+		t.Run("should fail when the context is canceled", func(t *testing.T) {
+			// given
+			cl, objs := setup(t)
+			ctx, cancel := context.WithCancel(context.TODO())
+			cancel()
+			cl.MockList = func(ctx context.Context, list runtime.Object, opts ...runtimeclient.ListOption) error {
+				return ctx.Err()
+			}
+
+			// when
+			_, err := client.NewApplyClient(cl, s).PruneToolchainObjectsWithContext(ctx, objs, labels, []schema.GroupVersionKind{roleBindingGVK})
+
+			// then
+			require.EqualError(t, err, "unable to list the resources of kind: RoleBinding, version: v1: context canceled")
+			assertRoleBindingExists(t, cl, user, labels)
+		})
+
+		t.Run("should fail when object cannot be deleted", func(t *testing.T) {
+			// given
+			cl, objs := setup(t)
+			cl.MockDelete = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.DeleteOption) error {
+				return fmt.Errorf("unable to delete")
+			}
+
+			// when
+			_, err := client.NewApplyClient(cl, s).PruneToolchainObjects(objs, labels, []schema.GroupVersionKind{roleBindingGVK})
+
+			// then
+			require.EqualError(t, err, "unable to delete the resource of kind: RoleBinding, version: v1: unable to delete")
+		})
+	})
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// ApplyAction the action that is (or would be) performed when an object is applied
+type ApplyAction string
+
+const (
+	// ApplyActionCreate the object does not exist and is (or would be) created
+	ApplyActionCreate ApplyAction = "create"
+	// ApplyActionUpdate the object exists and is (or would be) updated
+	ApplyActionUpdate ApplyAction = "update"
+	// ApplyActionUnchanged the object exists and is already in the desired state
+	ApplyActionUnchanged ApplyAction = "unchanged"
+	// ApplyActionError the object could not be applied
+	ApplyActionError ApplyAction = "error"
+)
+
+const (
+	// CreatedEventReason the reason of the event recorded on the owner when an object is created
+	CreatedEventReason = "Created"
+	// UpdatedEventReason the reason of the event recorded on the owner when an object is updated
+	UpdatedEventReason = "Updated"
+	// ApplyFailedEventReason the reason of the event recorded on the owner when an object could not be applied
+	ApplyFailedEventReason = "ApplyFailed"
+)
+
+// ApplyResult the result of applying an object
+type ApplyResult struct {
+	Action ApplyAction
+	// OldResourceVersion the resourceVersion of the object before it was applied (empty if the object did not exist)
+	OldResourceVersion string
+	// NewResourceVersion the resourceVersion of the object after it was applied
+	NewResourceVersion string
+}
+
+// newUpdateResult returns the result of the update of the given existing object. The object is considered as updated when
+// its resourceVersion changed, and when either its generation or its content (apart from the status and the metadata managed
+// by the server) changed. This way, the objects without generation such as the ConfigMaps and the Secrets are supported too.
+func newUpdateResult(existing, updated runtime.Object) (ApplyResult, error) {
+	metaExisting, err := meta.Accessor(existing)
+	if err != nil {
+		return ApplyResult{}, errors.Wrapf(err, "cannot get metadata from %+v", existing)
+	}
+	metaUpdated, err := meta.Accessor(updated)
+	if err != nil {
+		return ApplyResult{}, errors.Wrapf(err, "cannot get metadata from %+v", updated)
+	}
+	result := ApplyResult{
+		Action:             ApplyActionUnchanged,
+		OldResourceVersion: metaExisting.GetResourceVersion(),
+		NewResourceVersion: metaUpdated.GetResourceVersion(),
+	}
+	if result.OldResourceVersion == result.NewResourceVersion {
+		return result, nil
+	}
+	if metaExisting.GetGeneration() != metaUpdated.GetGeneration() {
+		result.Action = ApplyActionUpdate
+		return result, nil
+	}
+	same, err := sameContent(existing, updated)
+	if err != nil {
+		return result, err
+	}
+	if !same {
+		result.Action = ApplyActionUpdate
+	}
+	return result, nil
+}
+
+// sameContent checks if the given objects have the same content, apart from their kind, apiVersion, status
+// and the metadata managed by the server
+func sameContent(obj, otherObj runtime.Object) (bool, error) {
+	content, err := cleanContent(obj)
+	if err != nil {
+		return false, err
+	}
+	otherContent, err := cleanContent(otherObj)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(content, otherContent), nil
+}
+
+func cleanContent(obj runtime.Object) (map[string]interface{}, error) {
+	cleaned, err := marshalCleanJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+	content := map[string]interface{}{}
+	if err := json.Unmarshal(cleaned, &content); err != nil {
+		return nil, errors.Wrapf(err, "unable to unmarshal the object %v", obj)
+	}
+	delete(content, "kind")
+	delete(content, "apiVersion")
+	// the last applied configuration changes when the object is applied again, even if nothing else changed
+	if annotations, found, err := unstructured.NestedMap(content, "metadata", "annotations"); err == nil && found {
+		delete(annotations, LastAppliedConfigurationAnnotationKey)
+		if len(annotations) == 0 {
+			unstructured.RemoveNestedField(content, "metadata", "annotations")
+		} else if err := unstructured.SetNestedMap(content, annotations, "metadata", "annotations"); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// recordEvent records an event on the owner of the given object, if an event recorder and an owner were configured.
+// No event is recorded when the object is unchanged.
+func (p ApplyClient) recordEvent(config applyObjectConfiguration, obj runtime.Object, result ApplyResult, err error) {
+	if config.eventRecorder == nil || config.owner == nil {
+		return
+	}
+	owner, ok := config.owner.(runtime.Object)
+	if !ok {
+		return
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := apiutil.GVKForObject(obj, p.scheme); err == nil {
+		kind = gvk.Kind
+	}
+	name := ""
+	if metaObj, err := meta.Accessor(obj); err == nil {
+		name = metaObj.GetName()
+		if metaObj.GetNamespace() != "" {
+			name = metaObj.GetNamespace() + "/" + name
+		}
+	}
+	switch result.Action {
+	case ApplyActionCreate:
+		config.eventRecorder.Eventf(owner, corev1.EventTypeNormal, CreatedEventReason, "Created %s %s", kind, name)
+	case ApplyActionUpdate:
+		config.eventRecorder.Eventf(owner, corev1.EventTypeNormal, UpdatedEventReason, "Updated %s %s", kind, name)
+	case ApplyActionError:
+		config.eventRecorder.Eventf(owner, corev1.EventTypeWarning, ApplyFailedEventReason, "Unable to apply %s %s: %s", kind, name, err)
+	}
+}
@@ -0,0 +1,164 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestApplyObjectWithResult(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	newConfigMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "registration-service",
+				Namespace: "toolchain-host-operator",
+			},
+			Data: map[string]string{
+				"first-param": "first-value",
+			},
+		}
+	}
+
+	t.Run("should classify the changes", func(t *testing.T) {
+		// given
+		cl, _ := newClient(t, s)
+
+		// when
+		result, err := cl.ApplyObjectWithResult(context.TODO(), newConfigMap())
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, client.ApplyActionCreate, result.Action)
+		assert.Empty(t, result.OldResourceVersion)
+		assert.NotEmpty(t, result.NewResourceVersion)
+		created := result.NewResourceVersion
+
+		t.Run("unchanged when applied again", func(t *testing.T) {
+			// when
+			result, err := cl.ApplyObjectWithResult(context.TODO(), newConfigMap(), client.ForceUpdate(true))
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, client.ApplyActionUnchanged, result.Action)
+			assert.Equal(t, created, result.OldResourceVersion)
+		})
+
+		t.Run("updated when only the labels changed", func(t *testing.T) {
+			// given
+			modified := newConfigMap()
+			modified.Labels = map[string]string{"provider": "codeready-toolchain"}
+
+			// when
+			result, err := cl.ApplyObjectWithResult(context.TODO(), modified)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, client.ApplyActionUpdate, result.Action)
+			assert.NotEqual(t, result.OldResourceVersion, result.NewResourceVersion)
+		})
+
+		t.Run("updated with a three-way merge patch", func(t *testing.T) {
+			// given
+			modified := newConfigMap()
+			modified.Data["first-param"] = "second-value"
+
+			// when
+			result, err := cl.ApplyObjectWithResult(context.TODO(), modified, client.ThreeWayMergePatch(true))
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, client.ApplyActionUpdate, result.Action)
+			assert.NotEqual(t, result.OldResourceVersion, result.NewResourceVersion)
+		})
+	})
+
+	t.Run("should return error", func(t *testing.T) {
+		// given
+		cl, cli := newClient(t, s)
+		cli.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+			return fmt.Errorf("unable to create")
+		}
+
+		// when
+		result, err := cl.ApplyObjectWithResult(context.TODO(), newConfigMap())
+
+		// then
+		require.EqualError(t, err, "unable to create resource of kind: , version: : unable to create")
+		assert.Equal(t, client.ApplyActionError, result.Action)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		// given
+		owner := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "registration-service",
+				Namespace: "toolchain-host-operator",
+				UID:       "registration-service-uid",
+			},
+		}
+
+		t.Run("should record events on the owner", func(t *testing.T) {
+			// given
+			cl, _ := newClient(t, s)
+			recorder := record.NewFakeRecorder(10)
+
+			// when
+			_, err := cl.ApplyObjectWithResult(context.TODO(), newConfigMap(), client.SetOwner(owner), client.RecordEvents(recorder))
+			require.NoError(t, err)
+			_, err = cl.ApplyObjectWithResult(context.TODO(), newConfigMap(), client.SetOwner(owner), client.RecordEvents(recorder))
+			require.NoError(t, err)
+			modified := newConfigMap()
+			modified.Data["first-param"] = "second-value"
+			_, err = cl.ApplyObjectWithResult(context.TODO(), modified, client.SetOwner(owner), client.RecordEvents(recorder))
+			require.NoError(t, err)
+
+			// then
+			require.Len(t, recorder.Events, 2)
+			assert.Equal(t, "Normal Created Created ConfigMap toolchain-host-operator/registration-service", <-recorder.Events)
+			assert.Equal(t, "Normal Updated Updated ConfigMap toolchain-host-operator/registration-service", <-recorder.Events)
+		})
+
+		t.Run("should record warning event when failed", func(t *testing.T) {
+			// given
+			cl, cli := newClient(t, s)
+			cli.MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+				return fmt.Errorf("unable to create")
+			}
+			recorder := record.NewFakeRecorder(10)
+
+			// when
+			_, err := cl.ApplyObjectWithResult(context.TODO(), newConfigMap(), client.SetOwner(owner), client.RecordEvents(recorder))
+
+			// then
+			require.Error(t, err)
+			require.Len(t, recorder.Events, 1)
+			assert.Equal(t, "Warning ApplyFailed Unable to apply ConfigMap toolchain-host-operator/registration-service: unable to create", <-recorder.Events)
+		})
+
+		t.Run("should not record events without owner", func(t *testing.T) {
+			// given
+			cl, _ := newClient(t, s)
+			recorder := record.NewFakeRecorder(10)
+
+			// when
+			_, err := cl.ApplyObjectWithResult(context.TODO(), newConfigMap(), client.RecordEvents(recorder))
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, recorder.Events)
+		})
+	})
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// RetainFieldRule sets the value of some fields of the 'existing' object into the 'newResource' object,
+// typically because these fields are immutable or populated by the server, and the update would fail or reset them otherwise.
+// The rule must support both typed and unstructured objects.
+type RetainFieldRule func(newResource, existing runtime.Object) error
+
+var retainFieldRules = struct {
+	sync.RWMutex
+	rules map[schema.GroupKind][]RetainFieldRule
+}{
+	rules: map[schema.GroupKind][]RetainFieldRule{
+		// `Service "<name>" is invalid: spec.clusterIP: Invalid value: "": field is immutable`
+		{Group: "", Kind: "Service"}:               {RetainNestedFields([]string{"spec", "clusterIP"}), retainHealthCheckNodePort},
+		{Group: "", Kind: "PersistentVolumeClaim"}: {RetainNestedFields([]string{"spec", "volumeName"})},
+		{Group: "batch", Kind: "Job"}:              {RetainNestedFields([]string{"spec", "selector"}, []string{"spec", "template"})},
+		// the host is generated by the server only when it is not set, so it can still be changed
+		{Group: "route.openshift.io", Kind: "Route"}: {RetainNestedFieldsIfUnset([]string{"spec", "host"})},
+	},
+}
+
+// RegisterRetainFieldRule registers a rule to apply on the objects of the given group and kind (regardless of their version)
+// before they are updated. The rule is applied in addition to the ones that are already registered for the same group and kind.
+func RegisterRetainFieldRule(groupKind schema.GroupKind, rule RetainFieldRule) {
+	retainFieldRules.Lock()
+	defer retainFieldRules.Unlock()
+	retainFieldRules.rules[groupKind] = append(retainFieldRules.rules[groupKind], rule)
+}
+
+// RetainFields applies all the rules registered for the kind of the 'newResource' object, ie, it sets the values
+// of the retained fields from the given 'existing' object into the 'newResource' object.
+// The scheme is used to look-up the kind of the typed objects which have no TypeMeta.
+func RetainFields(scheme *runtime.Scheme, newResource, existing runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(newResource, scheme)
+	if err != nil {
+		return errors.Wrapf(err, "unable to get the GVK of the resource '%v'", newResource)
+	}
+	retainFieldRules.RLock()
+	rules := retainFieldRules.rules[gvk.GroupKind()]
+	retainFieldRules.RUnlock()
+	for _, retain := range rules {
+		if err := retain(newResource, existing); err != nil {
+			return errors.Wrapf(err, "unable to retain the fields of the resource of kind: %s", gvk.Kind)
+		}
+	}
+	return nil
+}
+
+// RetainNestedFields returns a rule which sets the values of the fields at the given paths (if they are set in the 'existing' object)
+// into the 'newResource' object
+func RetainNestedFields(paths ...[]string) RetainFieldRule {
+	return retainNestedFields(false, paths...)
+}
+
+// RetainNestedFieldsIfUnset returns a rule which sets the values of the fields at the given paths (if they are set in the 'existing' object)
+// into the 'newResource' object, but only for the fields which are not set (or empty) in the 'newResource' object.
+// It is meant for the mutable fields which are populated by the server when they are left empty.
+func RetainNestedFieldsIfUnset(paths ...[]string) RetainFieldRule {
+	return retainNestedFields(true, paths...)
+}
+
+func retainNestedFields(onlyIfUnset bool, paths ...[]string) RetainFieldRule {
+	return func(newResource, existing runtime.Object) error {
+		existingContent, err := toUnstructuredContent(existing)
+		if err != nil {
+			return err
+		}
+		newContent, err := toUnstructuredContent(newResource)
+		if err != nil {
+			return err
+		}
+		retained := false
+		for _, path := range paths {
+			value, found, err := unstructured.NestedFieldNoCopy(existingContent, path...)
+			if err != nil {
+				return err
+			}
+			if !found || value == nil {
+				continue
+			}
+			if onlyIfUnset {
+				newValue, found, err := unstructured.NestedFieldNoCopy(newContent, path...)
+				if err != nil {
+					return err
+				}
+				if found && !isEmptyValue(newValue) {
+					continue
+				}
+			}
+			if err := unstructured.SetNestedField(newContent, runtime.DeepCopyJSONValue(value), path...); err != nil {
+				return err
+			}
+			retained = true
+		}
+		if _, ok := newResource.(runtime.Unstructured); ok || !retained {
+			// the content of the unstructured object was changed in place
+			return nil
+		}
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(newContent, newResource)
+	}
+}
+
+// isEmptyValue checks if the given unstructured value is `nil`, an empty string or a zero number
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+// retainHealthCheckNodePort retains the `spec.healthCheckNodePort` of a Service if it is not set in the 'newResource' object,
+// but only if the 'newResource' object still needs it, ie, if it's a LoadBalancer Service with the `Local` external traffic policy.
+// Otherwise, the port must not be set, or the update is rejected by the server.
+func retainHealthCheckNodePort(newResource, existing runtime.Object) error {
+	newContent, err := toUnstructuredContent(newResource)
+	if err != nil {
+		return err
+	}
+	serviceType, _, err := unstructured.NestedString(newContent, "spec", "type")
+	if err != nil {
+		return err
+	}
+	trafficPolicy, _, err := unstructured.NestedString(newContent, "spec", "externalTrafficPolicy")
+	if err != nil {
+		return err
+	}
+	if serviceType != "LoadBalancer" || trafficPolicy != "Local" {
+		return nil
+	}
+	return RetainNestedFieldsIfUnset([]string{"spec", "healthCheckNodePort"})(newResource, existing)
+}
+
+// toUnstructuredContent returns the content of the given object as a map. For unstructured objects, the returned map is
+// the actual content of the object (not a copy).
+func toUnstructuredContent(obj runtime.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(runtime.Unstructured); ok {
+		return u.UnstructuredContent(), nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
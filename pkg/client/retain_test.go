@@ -0,0 +1,316 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRetainFields(t *testing.T) {
+	// given
+	s := addToScheme(t)
+
+	t.Run("Service", func(t *testing.T) {
+
+		t.Run("typed", func(t *testing.T) {
+			// given
+			newResource := &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:                  corev1.ServiceTypeLoadBalancer,
+					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+					Selector:              map[string]string{"run": "all-services"},
+				},
+			}
+			existing := &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					ClusterIP:           "10.2.3.4",
+					HealthCheckNodePort: 30000,
+					Selector:            map[string]string{"run": "registration-service"},
+				},
+			}
+
+			// when
+			err := client.RetainFields(s, newResource, existing)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "10.2.3.4", newResource.Spec.ClusterIP)
+			assert.Equal(t, int32(30000), newResource.Spec.HealthCheckNodePort)
+			assert.Equal(t, "all-services", newResource.Spec.Selector["run"])
+		})
+
+		t.Run("unstructured", func(t *testing.T) {
+			// given
+			newResource := newUnstructured("v1", "Service", map[string]interface{}{
+				"type":                  "LoadBalancer",
+				"externalTrafficPolicy": "Local",
+				"selector":              map[string]interface{}{"run": "all-services"},
+			})
+			existing := newUnstructured("v1", "Service", map[string]interface{}{
+				"clusterIP":           "10.2.3.4",
+				"healthCheckNodePort": int64(30000),
+			})
+
+			// when
+			err := client.RetainFields(s, newResource, existing)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{
+				"type":                  "LoadBalancer",
+				"externalTrafficPolicy": "Local",
+				"clusterIP":             "10.2.3.4",
+				"healthCheckNodePort":   int64(30000),
+				"selector":              map[string]interface{}{"run": "all-services"},
+			}, newResource.Object["spec"])
+		})
+
+		t.Run("health check node port not needed anymore", func(t *testing.T) {
+			// given
+			newResource := &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type: corev1.ServiceTypeClusterIP,
+				},
+			}
+			existing := &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:                  corev1.ServiceTypeLoadBalancer,
+					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+					ClusterIP:             "10.2.3.4",
+					HealthCheckNodePort:   30000,
+				},
+			}
+
+			// when
+			err := client.RetainFields(s, newResource, existing)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "10.2.3.4", newResource.Spec.ClusterIP)
+			assert.Zero(t, newResource.Spec.HealthCheckNodePort)
+		})
+
+		t.Run("health check node port set explicitly", func(t *testing.T) {
+			// given
+			newResource := &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:                  corev1.ServiceTypeLoadBalancer,
+					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+					HealthCheckNodePort:   30001,
+				},
+			}
+			existing := &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:                  corev1.ServiceTypeLoadBalancer,
+					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+					HealthCheckNodePort:   30000,
+				},
+			}
+
+			// when
+			err := client.RetainFields(s, newResource, existing)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, int32(30001), newResource.Spec.HealthCheckNodePort)
+		})
+	})
+
+	t.Run("PersistentVolumeClaim", func(t *testing.T) {
+		// given
+		newResource := newUnstructured("v1", "PersistentVolumeClaim", map[string]interface{}{})
+		existing := newUnstructured("v1", "PersistentVolumeClaim", map[string]interface{}{
+			"volumeName": "pv-0001",
+		})
+
+		// when
+		err := client.RetainFields(s, newResource, existing)
+
+		// then
+		require.NoError(t, err)
+		volumeName, found, err := unstructured.NestedString(newResource.Object, "spec", "volumeName")
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, "pv-0001", volumeName)
+	})
+
+	t.Run("Job", func(t *testing.T) {
+		// given
+		newResource := &batchv1.Job{}
+		existing := &batchv1.Job{
+			Spec: batchv1.JobSpec{
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"controller-uid": "123"},
+				},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"controller-uid": "123"},
+					},
+				},
+			},
+		}
+
+		// when
+		err := client.RetainFields(s, newResource, existing)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, existing.Spec.Selector, newResource.Spec.Selector)
+		assert.Equal(t, existing.Spec.Template, newResource.Spec.Template)
+	})
+
+	t.Run("Route", func(t *testing.T) {
+
+		t.Run("generated host", func(t *testing.T) {
+			// given
+			newResource := newUnstructured("route.openshift.io/v1", "Route", map[string]interface{}{})
+			existing := newUnstructured("route.openshift.io/v1", "Route", map[string]interface{}{
+				"host": "registration-service.apps.example.com",
+			})
+
+			// when
+			err := client.RetainFields(s, newResource, existing)
+
+			// then
+			require.NoError(t, err)
+			host, found, err := unstructured.NestedString(newResource.Object, "spec", "host")
+			require.NoError(t, err)
+			require.True(t, found)
+			assert.Equal(t, "registration-service.apps.example.com", host)
+		})
+
+		t.Run("changed host", func(t *testing.T) {
+			// given
+			newResource := newUnstructured("route.openshift.io/v1", "Route", map[string]interface{}{
+				"host": "new.example.com",
+			})
+			existing := newUnstructured("route.openshift.io/v1", "Route", map[string]interface{}{
+				"host": "old.example.com",
+			})
+
+			// when
+			err := client.RetainFields(s, newResource, existing)
+
+			// then
+			require.NoError(t, err)
+			host, found, err := unstructured.NestedString(newResource.Object, "spec", "host")
+			require.NoError(t, err)
+			require.True(t, found)
+			assert.Equal(t, "new.example.com", host)
+		})
+	})
+
+	t.Run("kind without rules", func(t *testing.T) {
+		// given
+		newResource := &corev1.ConfigMap{
+			Data: map[string]string{"first-param": "second-value"},
+		}
+		existing := &corev1.ConfigMap{
+			Data: map[string]string{"first-param": "first-value"},
+		}
+
+		// when
+		err := client.RetainFields(s, newResource, existing)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "second-value", newResource.Data["first-param"])
+	})
+
+	t.Run("custom rule", func(t *testing.T) {
+		// given
+		client.RegisterRetainFieldRule(schema.GroupKind{Group: "toolchain.dev.openshift.com", Kind: "Custom"},
+			client.RetainNestedFields([]string{"spec", "generated"}))
+		client.RegisterRetainFieldRule(schema.GroupKind{Group: "toolchain.dev.openshift.com", Kind: "Custom"},
+			func(newResource, existing runtime.Object) error {
+				return unstructured.SetNestedField(newResource.(*unstructured.Unstructured).Object, "custom", "spec", "other")
+			})
+		newResource := newUnstructured("toolchain.dev.openshift.com/v1alpha1", "Custom", map[string]interface{}{})
+		existing := newUnstructured("toolchain.dev.openshift.com/v1alpha1", "Custom", map[string]interface{}{
+			"generated": "abcd",
+		})
+
+		// when
+		err := client.RetainFields(s, newResource, existing)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"generated": "abcd",
+			"other":     "custom",
+		}, newResource.Object["spec"])
+
+		t.Run("failing rule", func(t *testing.T) {
+			// given
+			client.RegisterRetainFieldRule(schema.GroupKind{Group: "toolchain.dev.openshift.com", Kind: "Failing"},
+				func(newResource, existing runtime.Object) error {
+					return fmt.Errorf("failed")
+				})
+			newResource := newUnstructured("toolchain.dev.openshift.com/v1alpha1", "Failing", map[string]interface{}{})
+
+			// when
+			err := client.RetainFields(s, newResource, newResource.DeepCopy())
+
+			// then
+			require.EqualError(t, err, "unable to retain the fields of the resource of kind: Failing: failed")
+		})
+	})
+}
+
+func TestApplyObjectRetainsFields(t *testing.T) {
+	// given
+	s := addToScheme(t)
+	cl, cli := newClient(t, s)
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "data",
+			Namespace: "toolchain-host-operator",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		},
+	}
+	_, err := cl.ApplyObject(pvc.DeepCopy())
+	require.NoError(t, err)
+	// the volume gets bound
+	existing := &corev1.PersistentVolumeClaim{}
+	err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "data"}, existing)
+	require.NoError(t, err)
+	existing.Spec.VolumeName = "pv-0001"
+	err = cli.Update(context.TODO(), existing)
+	require.NoError(t, err)
+
+	// when
+	modified := pvc.DeepCopy()
+	modified.Labels = map[string]string{"provider": "codeready-toolchain"}
+	_, err = cl.ApplyObject(modified)
+
+	// then
+	require.NoError(t, err)
+	err = cli.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "data"}, existing)
+	require.NoError(t, err)
+	assert.Equal(t, "pv-0001", existing.Spec.VolumeName)
+	assert.Equal(t, "codeready-toolchain", existing.Labels["provider"])
+}
+
+func newUnstructured(apiVersion, kind string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"spec":       spec,
+		},
+	}
+}
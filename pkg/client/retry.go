@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MutateFunc applies the desired changes on the given object, before it is updated.
+// It can be called several times, each time with the latest version of the object retrieved from the cluster.
+type MutateFunc func(obj runtime.Object) error
+
+type retryConfiguration struct {
+	backoff wait.Backoff
+}
+
+func newRetryConfiguration(options ...RetryOption) retryConfiguration {
+	config := retryConfiguration{
+		backoff: retry.DefaultRetry,
+	}
+	for _, apply := range options {
+		apply(&config)
+	}
+	return config
+}
+
+// RetryOption an option when updating a resource with retries
+type RetryOption func(*retryConfiguration)
+
+// RetryBackoff sets the backoff to wait between two attempts (default: `retry.DefaultRetry`)
+func RetryBackoff(backoff wait.Backoff) RetryOption {
+	return func(config *retryConfiguration) {
+		config.backoff = backoff
+	}
+}
+
+// UpdateWithRetry applies the given mutation on the given object and updates it. If the update fails because of a conflict,
+// then the object is retrieved again, the mutation is applied again on it and the update is retried, with a backoff.
+// The given object contains the result of the last attempt.
+func UpdateWithRetry(ctx context.Context, cl client.Client, obj runtime.Object, mutate MutateFunc, options ...RetryOption) error {
+	return updateWithRetry(ctx, cl, obj, mutate, func(ctx context.Context, obj runtime.Object) error {
+		return cl.Update(ctx, obj)
+	}, options...)
+}
+
+// UpdateStatusWithRetry does the same as UpdateWithRetry, but updates the status of the object
+func UpdateStatusWithRetry(ctx context.Context, cl client.Client, obj runtime.Object, mutate MutateFunc, options ...RetryOption) error {
+	return updateWithRetry(ctx, cl, obj, mutate, func(ctx context.Context, obj runtime.Object) error {
+		return cl.Status().Update(ctx, obj)
+	}, options...)
+}
+
+func updateWithRetry(ctx context.Context, cl client.Client, obj runtime.Object, mutate MutateFunc, update func(context.Context, runtime.Object) error, options ...RetryOption) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get metadata from %+v", obj)
+	}
+	namespacedName := types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}
+	config := newRetryConfiguration(options...)
+	attempt := 0
+	err = retry.RetryOnConflict(config.backoff, func() error {
+		attempt++
+		// the given object is used as-is for the first attempt, and then retrieved again after each conflict
+		if attempt > 1 {
+			if err := cl.Get(ctx, namespacedName, resetObject(obj)); err != nil {
+				return err
+			}
+		}
+		if err := mutate(obj); err != nil {
+			return err
+		}
+		return update(ctx, obj)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to update the resource '%s' after %d attempt(s)", namespacedName, attempt)
+	}
+	return nil
+}
+
+// resetObject resets all the fields of the given object, except its GroupVersionKind. Otherwise, retrieving the object
+// would merge the maps (such as the labels or the data), so the keys removed in the cluster in the meantime would be kept
+// in the object and written back by the update
+func resetObject(obj runtime.Object) runtime.Object {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	value := reflect.ValueOf(obj).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return obj
+}
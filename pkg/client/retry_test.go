@@ -0,0 +1,208 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestUpdateWithRetry(t *testing.T) {
+	// given
+	namespacedName := types.NamespacedName{Namespace: "toolchain-host-operator", Name: "registration-service"}
+	newConfigMap := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      namespacedName.Name,
+				Namespace: namespacedName.Namespace,
+			},
+			Data: map[string]string{
+				"first-param": "first-value",
+			},
+		}
+	}
+	setParam := func(obj runtime.Object) error {
+		obj.(*corev1.ConfigMap).Data["second-param"] = "second-value"
+		return nil
+	}
+
+	t.Run("should update without conflict", func(t *testing.T) {
+		// given
+		configMap := newConfigMap()
+		cl := NewFakeClient(t, configMap)
+
+		// when
+		err := client.UpdateWithRetry(context.TODO(), cl, configMap, setParam)
+
+		// then
+		require.NoError(t, err)
+		assertConfigMapData(t, cl, namespacedName, map[string]string{
+			"first-param":  "first-value",
+			"second-param": "second-value",
+		})
+	})
+
+	t.Run("should retry on conflict with the latest version", func(t *testing.T) {
+		// given
+		configMap := newConfigMap()
+		cl := NewFakeClient(t, configMap)
+		// the object is modified by someone else in the meantime
+		modified := configMap.DeepCopy()
+		modified.Data["third-param"] = "third-value"
+		err := cl.Update(context.TODO(), modified)
+		require.NoError(t, err)
+
+		// when
+		err = client.UpdateWithRetry(context.TODO(), cl, configMap, setParam)
+
+		// then
+		require.NoError(t, err)
+		assertConfigMapData(t, cl, namespacedName, map[string]string{
+			"first-param":  "first-value",
+			"second-param": "second-value",
+			"third-param":  "third-value",
+		})
+	})
+
+	t.Run("should retry on conflict without restoring the keys removed in the meantime", func(t *testing.T) {
+		// given
+		configMap := newConfigMap()
+		configMap.Data["third-param"] = "third-value"
+		cl := NewFakeClient(t, configMap)
+		// the key is removed by someone else in the meantime
+		modified := configMap.DeepCopy()
+		delete(modified.Data, "third-param")
+		err := cl.Update(context.TODO(), modified)
+		require.NoError(t, err)
+
+		// when
+		err = client.UpdateWithRetry(context.TODO(), cl, configMap, setParam)
+
+		// then
+		require.NoError(t, err)
+		assertConfigMapData(t, cl, namespacedName, map[string]string{
+			"first-param":  "first-value",
+			"second-param": "second-value",
+		})
+	})
+
+	t.Run("should retry on conflict with an unstructured object", func(t *testing.T) {
+		// given
+		configMap := newConfigMap()
+		configMap.Data["third-param"] = "third-value"
+		cl := NewFakeClient(t, configMap)
+		modified := configMap.DeepCopy()
+		delete(modified.Data, "third-param")
+		err := cl.Update(context.TODO(), modified)
+		require.NoError(t, err)
+		configMap.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+		obj, err := toUnstructured(configMap)
+		require.NoError(t, err)
+
+		// when
+		err = client.UpdateWithRetry(context.TODO(), cl, obj, func(obj runtime.Object) error {
+			return unstructured.SetNestedField(obj.(*unstructured.Unstructured).Object, "second-value", "data", "second-param")
+		})
+
+		// then
+		require.NoError(t, err)
+		assertConfigMapData(t, cl, namespacedName, map[string]string{
+			"first-param":  "first-value",
+			"second-param": "second-value",
+		})
+	})
+
+	t.Run("should fail when conflicts persist", func(t *testing.T) {
+		// given
+		configMap := newConfigMap()
+		cl := NewFakeClient(t, configMap)
+		cl.MockUpdate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.UpdateOption) error {
+			return apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, namespacedName.Name, fmt.Errorf("modified"))
+		}
+
+		// when
+		err := client.UpdateWithRetry(context.TODO(), cl, configMap, setParam, client.RetryBackoff(wait.Backoff{
+			Steps:    3,
+			Duration: time.Millisecond,
+			Factor:   1.0,
+		}))
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to update the resource 'toolchain-host-operator/registration-service' after 3 attempt(s)")
+		assert.True(t, apierrors.IsConflict(errors.Cause(err)))
+	})
+
+	t.Run("should not retry when mutation fails", func(t *testing.T) {
+		// given
+		configMap := newConfigMap()
+		cl := NewFakeClient(t, configMap)
+
+		// when
+		err := client.UpdateWithRetry(context.TODO(), cl, configMap, func(obj runtime.Object) error {
+			return fmt.Errorf("mock error")
+		})
+
+		// then
+		require.EqualError(t, err, "unable to update the resource 'toolchain-host-operator/registration-service' after 1 attempt(s): mock error")
+	})
+}
+
+func TestUpdateStatusWithRetry(t *testing.T) {
+	// given
+	toolchainCluster := &toolchainv1alpha1.ToolchainCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "member-cluster",
+			Namespace: "toolchain-host-operator",
+		},
+	}
+	cl := NewFakeClient(t, toolchainCluster)
+	modified := toolchainCluster.DeepCopy()
+	modified.Labels = map[string]string{"type": "member"}
+	err := cl.Update(context.TODO(), modified)
+	require.NoError(t, err)
+
+	// when
+	err = client.UpdateStatusWithRetry(context.TODO(), cl, toolchainCluster, func(obj runtime.Object) error {
+		obj.(*toolchainv1alpha1.ToolchainCluster).Status.Conditions = []toolchainv1alpha1.ToolchainClusterCondition{
+			{
+				Type:   toolchainv1alpha1.ToolchainClusterReady,
+				Status: corev1.ConditionTrue,
+			},
+		}
+		return nil
+	})
+
+	// then
+	require.NoError(t, err)
+	actual := &toolchainv1alpha1.ToolchainCluster{}
+	err = cl.Get(context.TODO(), types.NamespacedName{Namespace: "toolchain-host-operator", Name: "member-cluster"}, actual)
+	require.NoError(t, err)
+	assert.Equal(t, "member", actual.Labels["type"])
+	require.Len(t, actual.Status.Conditions, 1)
+	assert.Equal(t, corev1.ConditionTrue, actual.Status.Conditions[0].Status)
+}
+
+func assertConfigMapData(t *testing.T, cl runtimeclient.Client, namespacedName types.NamespacedName, expected map[string]string) {
+	configMap := &corev1.ConfigMap{}
+	err := cl.Get(context.TODO(), namespacedName, configMap)
+	require.NoError(t, err)
+	assert.Equal(t, expected, configMap.Data)
+}
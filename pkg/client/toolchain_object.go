@@ -21,6 +21,35 @@ type ToolchainObject interface {
 	HasSameGvk(otherObject ToolchainObject) bool
 	HasSameName(otherObject ToolchainObject) bool
 	HasSameGvkAndName(otherObject ToolchainObject) bool
+	GetKey() ToolchainObjectKey
+	HasSameKey(otherObject ToolchainObject) bool
+}
+
+// ToolchainObjectKey is the canonical identity of a ToolchainObject: its GVK, namespace and name.
+// It is comparable, so it can be used as a map key. Since the same object can be served in several versions of its group
+// (eg: `v1beta1` and `v1`), the objects are identified by the key returned by WithoutVersion when they are compared
+// (see HasSameKey, ToolchainObjectSet, BatchApplyToolchainObjects and PruneToolchainObjects).
+type ToolchainObjectKey struct {
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+}
+
+// String returns the canonical representation of the key, ie, `<apiVersion>/<kind>/<namespace>/<name>`
+// or `<apiVersion>/<kind>/<name>` for cluster-scoped objects
+func (k ToolchainObjectKey) String() string {
+	apiVersion, kind := k.GroupVersionKind.ToAPIVersionAndKind()
+	if k.Namespace == "" {
+		return fmt.Sprintf("%s/%s/%s", apiVersion, kind, k.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", apiVersion, kind, k.Namespace, k.Name)
+}
+
+// WithoutVersion returns a copy of the key without the version, ie, which identifies the object regardless of the version
+// it is served in
+func (k ToolchainObjectKey) WithoutVersion() ToolchainObjectKey {
+	k.GroupVersionKind.Version = ""
+	return k
 }
 
 // ComparableToolchainObject is a ToolchainObject providing a method to compare it with another instance of ToolchainObject
@@ -67,16 +96,31 @@ func (o *toolchainObjectImpl) HasSameGvk(otherObject ToolchainObject) bool {
 	return o.gvk == otherObject.GetGvk()
 }
 
-// HasSameName returns if the provided ToolchainObject has the same name
+// HasSameName returns if the provided ToolchainObject has the same name (regardless of the namespace)
 func (o *toolchainObjectImpl) HasSameName(otherObject ToolchainObject) bool {
 	return o.GetName() == otherObject.GetName()
 }
 
-// HasSameGvkAndName returns if the provided ToolchainObject has the same GVK and name
+// HasSameGvkAndName returns if the provided ToolchainObject has the same GVK and name (regardless of the namespace).
+// Use HasSameKey to also compare the namespaces.
 func (o *toolchainObjectImpl) HasSameGvkAndName(otherObject ToolchainObject) bool {
 	return o.HasSameGvk(otherObject) && o.HasSameName(otherObject)
 }
 
+// GetKey returns the key (GVK, namespace and name) of the ToolchainObject
+func (o *toolchainObjectImpl) GetKey() ToolchainObjectKey {
+	return ToolchainObjectKey{
+		GroupVersionKind: o.gvk,
+		Namespace:        o.GetNamespace(),
+		Name:             o.GetName(),
+	}
+}
+
+// HasSameKey returns if the provided ToolchainObject has the same group, kind, namespace and name (regardless of the version)
+func (o *toolchainObjectImpl) HasSameKey(otherObject ToolchainObject) bool {
+	return o.GetKey().WithoutVersion() == otherObject.GetKey().WithoutVersion()
+}
+
 type comparableToolchainObjectImpl struct {
 	ToolchainObject
 	compare CompareToolchainObjects
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ToolchainObjectSet is a set of ToolchainObjects where each object is identified by its key without the version
+// (group, kind, namespace and name), see ToolchainObjectKey. The objects are kept in the order they were inserted in.
+type ToolchainObjectSet struct {
+	keys    []ToolchainObjectKey
+	objects map[ToolchainObjectKey]ToolchainObject
+}
+
+// NewToolchainObjectSet returns a set containing the given objects. If several objects have the same key,
+// then the last one is kept.
+func NewToolchainObjectSet(objs ...ToolchainObject) *ToolchainObjectSet {
+	set := &ToolchainObjectSet{
+		objects: map[ToolchainObjectKey]ToolchainObject{},
+	}
+	set.Insert(objs...)
+	return set
+}
+
+// Insert adds the given objects to the set. An object with the same key as an object which is already
+// in the set replaces it (at the same position).
+func (s *ToolchainObjectSet) Insert(objs ...ToolchainObject) {
+	for _, obj := range objs {
+		key := obj.GetKey().WithoutVersion()
+		if _, found := s.objects[key]; !found {
+			s.keys = append(s.keys, key)
+		}
+		s.objects[key] = obj
+	}
+}
+
+// Delete removes the objects with the same key as the given objects from the set
+func (s *ToolchainObjectSet) Delete(objs ...ToolchainObject) {
+	for _, obj := range objs {
+		key := obj.GetKey().WithoutVersion()
+		if _, found := s.objects[key]; !found {
+			continue
+		}
+		delete(s.objects, key)
+		for i, k := range s.keys {
+			if k == key {
+				s.keys = append(s.keys[:i], s.keys[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Has returns if the set contains an object with the same key as the given object
+func (s *ToolchainObjectSet) Has(obj ToolchainObject) bool {
+	_, found := s.objects[obj.GetKey().WithoutVersion()]
+	return found
+}
+
+// Get returns the object of the set with the same key as the given object
+func (s *ToolchainObjectSet) Get(obj ToolchainObject) (ToolchainObject, bool) {
+	existing, found := s.objects[obj.GetKey().WithoutVersion()]
+	return existing, found
+}
+
+// Len returns the number of objects in the set
+func (s *ToolchainObjectSet) Len() int {
+	return len(s.keys)
+}
+
+// List returns the objects of the set, in the order they were inserted in
+func (s *ToolchainObjectSet) List() []ToolchainObject {
+	objs := make([]ToolchainObject, 0, len(s.keys))
+	for _, key := range s.keys {
+		objs = append(objs, s.objects[key])
+	}
+	return objs
+}
+
+// Union returns a new set containing the objects of this set and the objects of the other set which are not in this set
+func (s *ToolchainObjectSet) Union(other *ToolchainObjectSet) *ToolchainObjectSet {
+	result := NewToolchainObjectSet(s.List()...)
+	for _, obj := range other.List() {
+		if !result.Has(obj) {
+			result.Insert(obj)
+		}
+	}
+	return result
+}
+
+// Difference returns a new set containing the objects of this set which are not in the other set
+func (s *ToolchainObjectSet) Difference(other *ToolchainObjectSet) *ToolchainObjectSet {
+	result := NewToolchainObjectSet()
+	for _, obj := range s.List() {
+		if !other.Has(obj) {
+			result.Insert(obj)
+		}
+	}
+	return result
+}
+
+// Intersection returns a new set containing the objects of this set which are also in the other set
+func (s *ToolchainObjectSet) Intersection(other *ToolchainObjectSet) *ToolchainObjectSet {
+	result := NewToolchainObjectSet()
+	for _, obj := range s.List() {
+		if other.Has(obj) {
+			result.Insert(obj)
+		}
+	}
+	return result
+}
+
+// ToolchainObjectsDiff contains the changes to apply so that the actual objects match the desired objects
+type ToolchainObjectsDiff struct {
+	// ToCreate the desired objects which don't exist yet
+	ToCreate []ToolchainObject
+	// ToUpdate the desired objects which exist but are not the same as the actual ones
+	ToUpdate []ToolchainObject
+	// ToDelete the actual objects which are not desired anymore
+	ToDelete []ToolchainObject
+}
+
+// Diff computes the changes to apply so that the given actual objects match the objects of this (desired) set.
+// A desired object which also exists in the actual set is to be updated unless it's a ComparableToolchainObject
+// and its IsSame function returns `true` for the actual object. Desired objects which are not comparable are always to be updated.
+func (s *ToolchainObjectSet) Diff(actual *ToolchainObjectSet) (ToolchainObjectsDiff, error) {
+	diff := ToolchainObjectsDiff{
+		ToCreate: s.Difference(actual).List(),
+		ToDelete: actual.Difference(s).List(),
+	}
+	for _, desired := range s.Intersection(actual).List() {
+		if comparableObj, ok := desired.(ComparableToolchainObject); ok {
+			existing, _ := actual.Get(desired)
+			same, err := comparableObj.IsSame(existing)
+			if err != nil {
+				return diff, errors.Wrapf(err, "unable to compare the resource of kind: %s, namespace: %s, name: %s",
+					desired.GetGvk().Kind, desired.GetNamespace(), desired.GetName())
+			}
+			if same {
+				continue
+			}
+		}
+		diff.ToUpdate = append(diff.ToUpdate, desired)
+	}
+	return diff, nil
+}
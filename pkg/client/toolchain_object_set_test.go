@@ -0,0 +1,145 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestToolchainObjectSet(t *testing.T) {
+	// given
+	roleBindingA := newToolchainObject(t, newRoleBinding("a"))
+	roleBindingB := newToolchainObject(t, newRoleBinding("b"))
+	roleA := newToolchainObject(t, newRole("a"))
+	otherNsRole := newRole("a")
+	otherNsRole.Namespace = "other-namespace"
+	otherNsRoleA := newToolchainObject(t, otherNsRole)
+
+	t.Run("should identify objects by GVK, namespace and name", func(t *testing.T) {
+		// when
+		set := NewToolchainObjectSet(roleBindingA, roleA, otherNsRoleA, newToolchainObject(t, newRoleBinding("a")))
+
+		// then
+		assert.Equal(t, 3, set.Len())
+		assert.True(t, set.Has(roleBindingA))
+		assert.True(t, set.Has(roleA))
+		assert.True(t, set.Has(otherNsRoleA))
+		assert.False(t, set.Has(roleBindingB))
+	})
+
+	t.Run("should identify objects regardless of their version", func(t *testing.T) {
+		// given
+		rb := newRoleBinding("a")
+		rb.APIVersion = "rbac.authorization.k8s.io/v1beta1"
+		v1beta1RoleBindingA := newToolchainObject(t, rb)
+
+		// when
+		set := NewToolchainObjectSet(roleBindingA, v1beta1RoleBindingA)
+
+		// then
+		assert.Equal(t, 1, set.Len())
+		assert.True(t, set.Has(roleBindingA))
+		existing, found := set.Get(roleBindingA)
+		assert.True(t, found)
+		assert.Same(t, v1beta1RoleBindingA, existing)
+	})
+
+	t.Run("should keep the insertion order when replacing and deleting", func(t *testing.T) {
+		// given
+		set := NewToolchainObjectSet(roleBindingA, roleA, roleBindingB)
+		replacement := newToolchainObject(t, newRole("a"))
+
+		// when
+		set.Insert(replacement)
+		set.Delete(roleBindingA)
+
+		// then
+		require.Len(t, set.List(), 2)
+		assert.Same(t, replacement, set.List()[0])
+		assert.Same(t, roleBindingB, set.List()[1])
+		existing, found := set.Get(roleA)
+		assert.True(t, found)
+		assert.Same(t, replacement, existing)
+	})
+
+	t.Run("union, difference and intersection", func(t *testing.T) {
+		// given
+		first := NewToolchainObjectSet(roleBindingA, roleA)
+		second := NewToolchainObjectSet(roleA, roleBindingB)
+
+		// when
+		union := first.Union(second)
+		difference := first.Difference(second)
+		intersection := first.Intersection(second)
+
+		// then
+		assert.Equal(t, []ToolchainObject{roleBindingA, roleA, roleBindingB}, union.List())
+		assert.Equal(t, []ToolchainObject{roleBindingA}, difference.List())
+		assert.Equal(t, []ToolchainObject{roleA}, intersection.List())
+		// the original sets are unchanged
+		assert.Equal(t, 2, first.Len())
+		assert.Equal(t, 2, second.Len())
+	})
+}
+
+func TestToolchainObjectSetDiff(t *testing.T) {
+	// given
+	same := func(firstObject, secondObject ToolchainObject) (bool, error) {
+		return true, nil
+	}
+	notSame := func(firstObject, secondObject ToolchainObject) (bool, error) {
+		return false, nil
+	}
+	actual := NewToolchainObjectSet(
+		newToolchainObject(t, newRoleBinding("unchanged")),
+		newToolchainObject(t, newRoleBinding("changed")),
+		newToolchainObject(t, newRoleBinding("not-comparable")),
+		newToolchainObject(t, newRoleBinding("obsolete")))
+
+	t.Run("should compute objects to create, update and delete", func(t *testing.T) {
+		// given
+		unchanged := newComparableToolchainObject(t, newRoleBinding("unchanged"), same)
+		changed := newComparableToolchainObject(t, newRoleBinding("changed"), notSame)
+		notComparable := newToolchainObject(t, newRoleBinding("not-comparable"))
+		added := newToolchainObject(t, newRoleBinding("added"))
+		desired := NewToolchainObjectSet(unchanged, changed, notComparable, added)
+
+		// when
+		diff, err := desired.Diff(actual)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []ToolchainObject{added}, diff.ToCreate)
+		assert.Equal(t, []ToolchainObject{changed, notComparable}, diff.ToUpdate)
+		require.Len(t, diff.ToDelete, 1)
+		assert.Equal(t, "obsolete", diff.ToDelete[0].GetName())
+	})
+
+	t.Run("should return error when comparison fails", func(t *testing.T) {
+		// given
+		desired := NewToolchainObjectSet(newComparableToolchainObject(t, newRoleBinding("changed"), func(firstObject, secondObject ToolchainObject) (bool, error) {
+			return false, fmt.Errorf("some error")
+		}))
+
+		// when
+		_, err := desired.Diff(actual)
+
+		// then
+		require.EqualError(t, err, "unable to compare the resource of kind: RoleBinding, namespace: namespace-test, name: changed: some error")
+	})
+}
+
+func newToolchainObject(t *testing.T, obj runtime.Object) ToolchainObject {
+	toolchainObject, err := NewToolchainObject(obj)
+	require.NoError(t, err)
+	return toolchainObject
+}
+
+func newComparableToolchainObject(t *testing.T, obj runtime.Object, compare CompareToolchainObjects) ComparableToolchainObject {
+	toolchainObject, err := NewComparableToolchainObject(obj, compare)
+	require.NoError(t, err)
+	return toolchainObject
+}
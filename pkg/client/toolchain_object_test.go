@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -100,6 +101,66 @@ func TestToolchainObjectHasSameFunctions(t *testing.T) {
 	})
 }
 
+func TestToolchainObjectKey(t *testing.T) {
+	// given
+	roleBinding, err := NewToolchainObject(newRoleBinding("rb-test"))
+	require.NoError(t, err)
+	sameRoleBinding, err := NewToolchainObject(newRoleBinding("rb-test"))
+	require.NoError(t, err)
+	otherNsRb := newRoleBinding("rb-test")
+	otherNsRb.Namespace = "other-namespace"
+	otherNsRoleBinding, err := NewToolchainObject(otherNsRb)
+	require.NoError(t, err)
+
+	t.Run("have the same key", func(t *testing.T) {
+		// when
+		same := roleBinding.HasSameKey(sameRoleBinding)
+
+		// then
+		assert.True(t, same)
+		assert.Equal(t, roleBinding.GetKey(), sameRoleBinding.GetKey())
+	})
+
+	t.Run("don't have the same key when the namespaces are different", func(t *testing.T) {
+		// when
+		same := roleBinding.HasSameKey(otherNsRoleBinding)
+
+		// then
+		assert.False(t, same)
+		assert.True(t, roleBinding.HasSameGvkAndName(otherNsRoleBinding))
+		assert.NotEqual(t, roleBinding.GetKey(), otherNsRoleBinding.GetKey())
+	})
+
+	t.Run("have the same key when the versions are different", func(t *testing.T) {
+		// given
+		rb := newRoleBinding("rb-test")
+		rb.APIVersion = "rbac.authorization.k8s.io/v1beta1"
+		v1beta1RoleBinding, err := NewToolchainObject(rb)
+		require.NoError(t, err)
+
+		// when
+		same := roleBinding.HasSameKey(v1beta1RoleBinding)
+
+		// then
+		assert.True(t, same)
+		assert.NotEqual(t, roleBinding.GetKey(), v1beta1RoleBinding.GetKey())
+		assert.Equal(t, roleBinding.GetKey().WithoutVersion(), v1beta1RoleBinding.GetKey().WithoutVersion())
+	})
+
+	t.Run("canonical representation", func(t *testing.T) {
+		// given
+		namespace, err := NewToolchainObject(&corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: "john-dev"},
+		})
+		require.NoError(t, err)
+
+		// then
+		assert.Equal(t, "rbac.authorization.k8s.io/v1/RoleBinding/namespace-test/rb-test", roleBinding.GetKey().String())
+		assert.Equal(t, "v1/Namespace/john-dev", namespace.GetKey().String())
+	})
+}
+
 func TestNewComparableToolchainObject(t *testing.T) {
 	// given
 	rb := newRoleBinding("rb-test")
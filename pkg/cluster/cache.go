@@ -1,9 +1,11 @@
 package cluster
 
 import (
+	"reflect"
 	"sync"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	kubeclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -38,18 +40,78 @@ type CachedToolchainCluster struct {
 	// then the OwnerClusterName has a name of the member - it has to be same name as the name
 	// that is used for identifying the member in a Host cluster
 	OwnerClusterName string
+
+	// clientset is created the first time it's needed (see Clientset), and is kept as long as the Config doesn't change
+	clientset kubeclientset.Interface
+	// clientsetConfig is the Config the clientset was created for
+	clientsetConfig *rest.Config
+	// proxyURL the URL of the proxy set in the transport wrapper of the Config (if any)
+	proxyURL string
+}
+
+// clientsetLock protects the lazy creation of the clientsets of all the clusters
+var clientsetLock sync.Mutex
+
+// Clientset returns the clientset of the cluster. It's created for the current Config the first time it's needed,
+// and then reused, so the transport and its connections are not recreated for every call.
+// It's created again if the Config was replaced.
+func (c *CachedToolchainCluster) Clientset() (kubeclientset.Interface, error) {
+	clientsetLock.Lock()
+	defer clientsetLock.Unlock()
+	if c.clientset == nil || c.clientsetConfig != c.Config {
+		clientset, err := kubeclientset.NewForConfig(c.Config)
+		if err != nil {
+			return nil, err
+		}
+		c.clientset = clientset
+		c.clientsetConfig = c.Config
+	}
+	return c.clientset, nil
+}
+
+// reuseClients sets the client, the config and the clientset of the given (previous) cluster in this cluster,
+// if the previous cluster has the same config, so they are not created again when nothing changed in the connection
+// to the cluster. Returns `true` if the clients were reused.
+func (c *CachedToolchainCluster) reuseClients(previous *CachedToolchainCluster) bool {
+	if previous == nil || previous.Config == nil || !sameConfig(previous.Config, c.Config) || previous.proxyURL != c.proxyURL {
+		return false
+	}
+	clientsetLock.Lock()
+	defer clientsetLock.Unlock()
+	c.Client = previous.Client
+	c.Config = previous.Config
+	c.clientset = previous.clientset
+	c.clientsetConfig = previous.clientsetConfig
+	return true
+}
+
+// sameConfig returns `true` if both configs are equal. The transport wrappers are funcs, which cannot be compared,
+// so they are ignored (the proxy URL of the clusters must be compared instead)
+func sameConfig(config, other *rest.Config) bool {
+	config, other = rest.CopyConfig(config), rest.CopyConfig(other)
+	config.WrapTransport, other.WrapTransport = nil, nil
+	return reflect.DeepEqual(config, other)
 }
 
 func (c *toolchainClusterClients) addCachedToolchainCluster(cluster *CachedToolchainCluster) {
 	c.Lock()
 	defer c.Unlock()
 	c.clusters[cluster.Name] = cluster
+	c.updateCachedClustersMetric()
 }
 
 func (c *toolchainClusterClients) deleteCachedToolchainCluster(name string) {
 	c.Lock()
 	defer c.Unlock()
 	delete(c.clusters, name)
+	c.updateCachedClustersMetric()
+}
+
+// lookupCachedToolchainCluster returns the cluster with the given name, without refreshing the cache if it's missing
+func (c *toolchainClusterClients) lookupCachedToolchainCluster(name string) *CachedToolchainCluster {
+	c.RLock()
+	defer c.RUnlock()
+	return c.clusters[name]
 }
 
 func (c *toolchainClusterClients) getCachedToolchainCluster(name string) (*CachedToolchainCluster, bool) {
@@ -6,9 +6,11 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
 )
 
 var getCachedToolchainClusterFuncs = []func(name string) (*CachedToolchainCluster, bool){
@@ -455,3 +457,54 @@ func newTestCachedToolchainCluster(t *testing.T, name string, clusterType Type,
 func resetClusterCache() {
 	clusterCache = toolchainClusterClients{clusters: map[string]*CachedToolchainCluster{}}
 }
+
+func TestCachedClustersMetric(t *testing.T) {
+	// given
+	defer resetClusterCache()
+	member1 := newTestCachedToolchainCluster(t, "member-1", Member, ready)
+	member2 := newTestCachedToolchainCluster(t, "member-2", Member, ready)
+	host := newTestCachedToolchainCluster(t, "host", Host, ready)
+
+	// when
+	clusterCache.addCachedToolchainCluster(member1)
+	clusterCache.addCachedToolchainCluster(member2)
+	clusterCache.addCachedToolchainCluster(host)
+	clusterCache.deleteCachedToolchainCluster("member-1")
+
+	// then
+	assert.Equal(t, 1.0, testutil.ToFloat64(cachedClustersGauge.WithLabelValues(string(Member))))
+	assert.Equal(t, 1.0, testutil.ToFloat64(cachedClustersGauge.WithLabelValues(string(Host))))
+}
+
+func TestClientset(t *testing.T) {
+	// given
+	cachedCluster := &CachedToolchainCluster{
+		Name:   "east",
+		Config: &rest.Config{Host: "http://cluster.com"},
+	}
+
+	t.Run("the clientset should be created once", func(t *testing.T) {
+		// when
+		first, err := cachedCluster.Clientset()
+		require.NoError(t, err)
+		second, err := cachedCluster.Clientset()
+
+		// then
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+	})
+
+	t.Run("the clientset should be created again when the config changed", func(t *testing.T) {
+		// given
+		first, err := cachedCluster.Clientset()
+		require.NoError(t, err)
+		cachedCluster.Config = &rest.Config{Host: "http://other-cluster.com"}
+
+		// when
+		second, err := cachedCluster.Clientset()
+
+		// then
+		require.NoError(t, err)
+		assert.NotSame(t, first, second)
+	})
+}
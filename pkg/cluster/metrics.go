@@ -0,0 +1,44 @@
+package cluster
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// cachedClustersGauge the number of clusters in the cache, by type
+	cachedClustersGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "toolchain_cluster_cache_clusters",
+		Help: "Number of ToolchainClusters in the cache, by type",
+	}, []string{"type"})
+
+	// cacheRefreshCounter the number of times the cache was refreshed
+	cacheRefreshCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "toolchain_cluster_cache_refresh_total",
+		Help: "Number of refreshes of the ToolchainCluster cache",
+	})
+
+	// cacheRefreshDuration the duration of the refreshes of the cache
+	cacheRefreshDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "toolchain_cluster_cache_refresh_duration_seconds",
+		Help:    "Duration of the refreshes of the ToolchainCluster cache, in seconds",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func init() {
+	// the metrics are exposed by the manager, which serves the controller-runtime registry
+	metrics.Registry.MustRegister(cachedClustersGauge, cacheRefreshCounter, cacheRefreshDuration)
+}
+
+// updateCachedClustersMetric sets the number of clusters in the cache, by type.
+// The caller must hold the lock of the cache.
+func (c *toolchainClusterClients) updateCachedClustersMetric() {
+	counts := map[Type]int{Member: 0, Host: 0}
+	for _, cluster := range c.clusters {
+		counts[cluster.Type]++
+	}
+	for clusterType, count := range counts {
+		cachedClustersGauge.WithLabelValues(string(clusterType)).Set(float64(count))
+	}
+}
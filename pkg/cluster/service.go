@@ -3,6 +3,9 @@ package cluster
 import (
 	"context"
 	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
@@ -12,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/transport"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -25,7 +29,27 @@ const (
 
 	toolchainAPIQPS   = 20.0
 	toolchainAPIBurst = 30
-	toolchainTokenKey = "token"
+
+	// the keys of the secret referenced by the ToolchainCluster, which define how to authenticate against the cluster
+	toolchainTokenKey      = "token"
+	toolchainTokenFileKey  = "tokenFile"
+	toolchainKubeconfigKey = "kubeconfig"
+	toolchainClientCertKey = v1.TLSCertKey
+	toolchainClientKeyKey  = v1.TLSPrivateKeyKey
+)
+
+// The annotations of the ToolchainCluster which configure the connection to the cluster
+const (
+	// ProxyURLAnnotation the URL of the proxy to use to connect to the cluster
+	ProxyURLAnnotation = toolchainv1alpha1.LabelKeyPrefix + "proxy-url"
+	// TLSServerNameAnnotation the server name to use for SNI and to verify the certificate of the cluster, instead of the host of the API endpoint
+	TLSServerNameAnnotation = toolchainv1alpha1.LabelKeyPrefix + "tls-server-name"
+	// InsecureSkipTLSVerifyAnnotation if `true`, then the certificate of the cluster is not verified. For dev environments only!
+	InsecureSkipTLSVerifyAnnotation = toolchainv1alpha1.LabelKeyPrefix + "insecure-skip-tls-verify"
+	// QPSAnnotation the maximum number of queries per second to the cluster (default: `20`)
+	QPSAnnotation = toolchainv1alpha1.LabelKeyPrefix + "qps"
+	// BurstAnnotation the maximum burst of queries to the cluster (default: `30`)
+	BurstAnnotation = toolchainv1alpha1.LabelKeyPrefix + "burst"
 )
 
 // ToolchainClusterService manages cached cluster kube clients and related ToolchainCluster CRDs
@@ -68,20 +92,21 @@ func (s *ToolchainClusterService) addToolchainCluster(toolchainCluster *toolchai
 	if err != nil {
 		return errors.Wrap(err, "cannot create ToolchainCluster Config")
 	}
-	cl, err := client.New(clusterConfig, client.Options{})
-	if err != nil {
-		return errors.Wrap(err, "cannot create ToolchainCluster client")
+
+	if clusterConfig.Insecure {
+		s.enrichLogger(toolchainCluster).Info("WARNING: the TLS certificate of the cluster is not verified, the connection is insecure",
+			"annotation", InsecureSkipTLSVerifyAnnotation)
 	}
 
 	cluster := &CachedToolchainCluster{
 		Name:              toolchainCluster.Name,
 		APIEndpoint:       toolchainCluster.Spec.APIEndpoint,
-		Client:            cl,
 		Config:            clusterConfig,
 		ClusterStatus:     &toolchainCluster.Status,
 		Type:              Type(toolchainCluster.Labels[labelType]),
 		OperatorNamespace: toolchainCluster.Labels[labelNamespace],
 		OwnerClusterName:  toolchainCluster.Labels[labelOwnerClusterName],
+		proxyURL:          toolchainCluster.Annotations[ProxyURLAnnotation],
 	}
 	if cluster.Type == "" {
 		cluster.Type = Member
@@ -93,6 +118,15 @@ func (s *ToolchainClusterService) addToolchainCluster(toolchainCluster *toolchai
 			cluster.OperatorNamespace = defaultMemberOperatorNamespace
 		}
 	}
+	// the clients are created again only if the config changed (ie, the ToolchainCluster or its secret changed),
+	// so the existing connections to the cluster are kept otherwise
+	if !cluster.reuseClients(clusterCache.lookupCachedToolchainCluster(toolchainCluster.Name)) {
+		cl, err := client.New(clusterConfig, client.Options{})
+		if err != nil {
+			return errors.Wrap(err, "cannot create ToolchainCluster client")
+		}
+		cluster.Client = cl
+	}
 
 	clusterCache.addCachedToolchainCluster(cluster)
 	return nil
@@ -106,6 +140,11 @@ func (s *ToolchainClusterService) DeleteToolchainCluster(name string) {
 }
 
 func (s *ToolchainClusterService) refreshCache() {
+	start := time.Now()
+	defer func() {
+		cacheRefreshCounter.Inc()
+		cacheRefreshDuration.Observe(time.Since(start).Seconds())
+	}()
 	toolchainClusters := &toolchainv1alpha1.ToolchainClusterList{}
 	if err := s.client.List(context.TODO(), toolchainClusters, &client.ListOptions{Namespace: s.namespace}); err != nil {
 		s.log.Error(err, "the cluster cache was not refreshed")
@@ -124,7 +163,15 @@ func (s *ToolchainClusterService) enrichLogger(cluster *toolchainv1alpha1.Toolch
 		WithValues("Request.Namespace", cluster.Namespace, "Request.Name", cluster.Name)
 }
 
-// NewClusterConfig generate a new cluster config by fetching the necessary info the given ToolchainCluster's associated Secret
+// NewClusterConfig generate a new cluster config by fetching the necessary info the given ToolchainCluster's associated Secret.
+// The way to authenticate against the cluster is detected from the keys of the secret, in this order:
+//   - `kubeconfig`: an embedded kubeconfig (which may use any credentials, including an exec plugin) whose current context is used
+//   - `tls.crt` and `tls.key`: a client certificate and its private key
+//   - `token`: a bearer token
+//   - `tokenFile`: the path to a file containing a bearer token (which is reloaded periodically)
+//
+// The connection can be configured with the annotations of the ToolchainCluster: see `ProxyURLAnnotation`, `TLSServerNameAnnotation`,
+// `InsecureSkipTLSVerifyAnnotation`, `QPSAnnotation` and `BurstAnnotation`.
 func NewClusterConfig(cl client.Client, toolchainCluster *toolchainv1alpha1.ToolchainCluster, timeout time.Duration) (*rest.Config, error) {
 	clusterName := toolchainCluster.Name
 
@@ -147,12 +194,7 @@ func NewClusterConfig(cl client.Client, toolchainCluster *toolchainv1alpha1.Tool
 		return nil, errors.Wrapf(err, "unable to get secret %s for cluster %s", name, clusterName)
 	}
 
-	token, tokenFound := secret.Data[toolchainTokenKey]
-	if !tokenFound || len(token) == 0 {
-		return nil, errors.Errorf("the secret for cluster %s is missing a non-empty value for %q", clusterName, toolchainTokenKey)
-	}
-
-	clusterConfig, err := clientcmd.BuildConfigFromFlags(apiEndpoint, "")
+	clusterConfig, err := newAuthenticatedConfig(clusterName, apiEndpoint, secret)
 	if err != nil {
 		return nil, err
 	}
@@ -161,15 +203,125 @@ func NewClusterConfig(cl client.Client, toolchainCluster *toolchainv1alpha1.Tool
 	if err != nil {
 		return nil, err
 	}
-	clusterConfig.CAData = ca
-	clusterConfig.BearerToken = string(token)
+	// an embedded kubeconfig may contain its own CA
+	if len(ca) > 0 || !hasValue(secret, toolchainKubeconfigKey) {
+		clusterConfig.CAData = ca
+	}
 	clusterConfig.QPS = toolchainAPIQPS
 	clusterConfig.Burst = toolchainAPIBurst
 	clusterConfig.Timeout = timeout
 
+	if err := applyConnectionSettings(clusterConfig, toolchainCluster); err != nil {
+		return nil, err
+	}
 	return clusterConfig, nil
 }
 
+// applyConnectionSettings sets the connection settings defined in the annotations of the given ToolchainCluster in the config
+func applyConnectionSettings(clusterConfig *rest.Config, toolchainCluster *toolchainv1alpha1.ToolchainCluster) error {
+	annotations := toolchainCluster.Annotations
+	if value, ok := annotations[ProxyURLAnnotation]; ok {
+		proxyURL, err := url.Parse(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for annotation %q of cluster %s", ProxyURLAnnotation, toolchainCluster.Name)
+		}
+		clusterConfig.WrapTransport = withProxy(proxyURL)
+	}
+	if value, ok := annotations[TLSServerNameAnnotation]; ok {
+		clusterConfig.ServerName = value
+	}
+	if value, ok := annotations[InsecureSkipTLSVerifyAnnotation]; ok {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for annotation %q of cluster %s", InsecureSkipTLSVerifyAnnotation, toolchainCluster.Name)
+		}
+		if insecure {
+			clusterConfig.Insecure = true
+			// the root certificates cannot be specified along with the insecure flag
+			clusterConfig.CAData = nil
+			clusterConfig.CAFile = ""
+		}
+	}
+	if value, ok := annotations[QPSAnnotation]; ok {
+		qps, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for annotation %q of cluster %s", QPSAnnotation, toolchainCluster.Name)
+		}
+		clusterConfig.QPS = float32(qps)
+	}
+	if value, ok := annotations[BurstAnnotation]; ok {
+		burst, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for annotation %q of cluster %s", BurstAnnotation, toolchainCluster.Name)
+		}
+		clusterConfig.Burst = burst
+	}
+	return nil
+}
+
+// withProxy returns a transport wrapper which sends the requests through the proxy with the given URL
+func withProxy(proxyURL *url.URL) transport.WrapperFunc {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		if t, ok := rt.(*http.Transport); ok {
+			// the transport may be shared with other configs, so the proxy is set in a copy
+			t = t.Clone()
+			t.Proxy = http.ProxyURL(proxyURL)
+			return t
+		}
+		return rt
+	}
+}
+
+// newAuthenticatedConfig returns the config to connect to the given API endpoint, with the credentials found in the secret
+func newAuthenticatedConfig(clusterName, apiEndpoint string, secret *v1.Secret) (*rest.Config, error) {
+	switch {
+	case hasValue(secret, toolchainKubeconfigKey):
+		clusterConfig, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[toolchainKubeconfigKey])
+		if err != nil {
+			return nil, errors.Wrapf(err, "the value for %q in the secret for cluster %s is not a valid kubeconfig", toolchainKubeconfigKey, clusterName)
+		}
+		// the endpoint of the ToolchainCluster takes precedence over the server of the kubeconfig
+		clusterConfig.Host = apiEndpoint
+		return clusterConfig, nil
+
+	case hasValue(secret, toolchainClientCertKey) || hasValue(secret, toolchainClientKeyKey):
+		for _, key := range []string{toolchainClientCertKey, toolchainClientKeyKey} {
+			if !hasValue(secret, key) {
+				return nil, errors.Errorf("the secret for cluster %s is missing a non-empty value for %q", clusterName, key)
+			}
+		}
+		clusterConfig, err := clientcmd.BuildConfigFromFlags(apiEndpoint, "")
+		if err != nil {
+			return nil, err
+		}
+		clusterConfig.CertData = secret.Data[toolchainClientCertKey]
+		clusterConfig.KeyData = secret.Data[toolchainClientKeyKey]
+		return clusterConfig, nil
+
+	case hasValue(secret, toolchainTokenKey):
+		clusterConfig, err := clientcmd.BuildConfigFromFlags(apiEndpoint, "")
+		if err != nil {
+			return nil, err
+		}
+		clusterConfig.BearerToken = string(secret.Data[toolchainTokenKey])
+		return clusterConfig, nil
+
+	case hasValue(secret, toolchainTokenFileKey):
+		clusterConfig, err := clientcmd.BuildConfigFromFlags(apiEndpoint, "")
+		if err != nil {
+			return nil, err
+		}
+		clusterConfig.BearerTokenFile = string(secret.Data[toolchainTokenFileKey])
+		return clusterConfig, nil
+	}
+	return nil, errors.Errorf("the secret for cluster %s is missing a non-empty value for %q (other supported credentials: %q, %q and %q, %q)",
+		clusterName, toolchainTokenKey, toolchainKubeconfigKey, toolchainClientCertKey, toolchainClientKeyKey, toolchainTokenFileKey)
+}
+
+func hasValue(secret *v1.Secret, key string) bool {
+	return len(secret.Data[key]) > 0
+}
+
 func IsReady(clusterStatus *toolchainv1alpha1.ToolchainClusterStatus) bool {
 	for _, condition := range clusterStatus.Conditions {
 		if condition.Type == toolchainv1alpha1.ToolchainClusterReady {
@@ -1,12 +1,15 @@
 package cluster_test
 
 import (
+	"net/http"
 	"testing"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAddToolchainClusterAsMember(t *testing.T) {
@@ -58,3 +61,206 @@ func TestDeleteToolchainClusterWhenDoesNotExist(t *testing.T) {
 		return nil
 	})
 }
+
+func TestNewClusterConfig(t *testing.T) {
+	// given
+	newClusterWithSecretData := func(t *testing.T, data map[string][]byte) (*toolchainv1alpha1.ToolchainCluster, *test.FakeClient) {
+		toolchainCluster, secret := test.NewToolchainCluster("east", "secret", toolchainv1alpha1.ToolchainClusterStatus{}, map[string]string{})
+		secret.Data = data
+		return toolchainCluster, test.NewFakeClient(t, toolchainCluster, secret)
+	}
+
+	t.Run("with token", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithSecretData(t, map[string][]byte{"token": []byte("mycooltoken")})
+
+		// when
+		config, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "http://cluster.com", config.Host)
+		assert.Equal(t, "mycooltoken", config.BearerToken)
+	})
+
+	t.Run("with token file", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithSecretData(t, map[string][]byte{"tokenFile": []byte("/var/run/secrets/token")})
+
+		// when
+		config, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "/var/run/secrets/token", config.BearerTokenFile)
+		assert.Empty(t, config.BearerToken)
+	})
+
+	t.Run("with client certificate", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithSecretData(t, map[string][]byte{
+			"tls.crt": []byte("cert"),
+			"tls.key": []byte("key"),
+		})
+
+		// when
+		config, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []byte("cert"), config.CertData)
+		assert.Equal(t, []byte("key"), config.KeyData)
+		assert.Empty(t, config.BearerToken)
+	})
+
+	t.Run("with client certificate but without private key", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithSecretData(t, map[string][]byte{
+			"tls.crt": []byte("cert"),
+			"token":   []byte("mycooltoken"),
+		})
+
+		// when
+		_, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.EqualError(t, err, `the secret for cluster east is missing a non-empty value for "tls.key"`)
+	})
+
+	t.Run("with kubeconfig", func(t *testing.T) {
+		// given
+		kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east.com:6443
+contexts:
+- name: east
+  context:
+    cluster: east
+    user: admin
+current-context: east
+users:
+- name: admin
+  user:
+    token: mykubeconfigtoken
+`
+		toolchainCluster, cl := newClusterWithSecretData(t, map[string][]byte{"kubeconfig": []byte(kubeconfig)})
+
+		// when
+		config, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "http://cluster.com", config.Host)
+		assert.Equal(t, "mykubeconfigtoken", config.BearerToken)
+	})
+
+	t.Run("with invalid kubeconfig", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithSecretData(t, map[string][]byte{"kubeconfig": []byte("invalid")})
+
+		// when
+		_, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `the value for "kubeconfig" in the secret for cluster east is not a valid kubeconfig`)
+	})
+
+	t.Run("without credentials", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithSecretData(t, map[string][]byte{"other": []byte("value")})
+
+		// when
+		_, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.EqualError(t, err, `the secret for cluster east is missing a non-empty value for "token" (other supported credentials: "kubeconfig", "tls.crt" and "tls.key", "tokenFile")`)
+	})
+}
+
+func TestNewClusterConfigWithConnectionSettings(t *testing.T) {
+	// given
+	newClusterWithAnnotations := func(t *testing.T, annotations map[string]string) (*toolchainv1alpha1.ToolchainCluster, *test.FakeClient) {
+		toolchainCluster, secret := test.NewToolchainCluster("east", "secret", toolchainv1alpha1.ToolchainClusterStatus{}, map[string]string{})
+		toolchainCluster.Spec.CABundle = "Y2EtYnVuZGxl" // "ca-bundle"
+		toolchainCluster.Annotations = annotations
+		return toolchainCluster, test.NewFakeClient(t, toolchainCluster, secret)
+	}
+
+	t.Run("without annotations", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithAnnotations(t, nil)
+
+		// when
+		config, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []byte("ca-bundle"), config.CAData)
+		assert.False(t, config.Insecure)
+		assert.Empty(t, config.ServerName)
+		assert.Nil(t, config.WrapTransport)
+		assert.Equal(t, float32(20), config.QPS)
+		assert.Equal(t, 30, config.Burst)
+	})
+
+	t.Run("with all annotations", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithAnnotations(t, map[string]string{
+			cluster.ProxyURLAnnotation:              "http://proxy.com:3128",
+			cluster.TLSServerNameAnnotation:         "api.cluster.com",
+			cluster.InsecureSkipTLSVerifyAnnotation: "true",
+			cluster.QPSAnnotation:                   "50.5",
+			cluster.BurstAnnotation:                 "100",
+		})
+
+		// when
+		config, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "api.cluster.com", config.ServerName)
+		assert.True(t, config.Insecure)
+		assert.Nil(t, config.CAData)
+		assert.Equal(t, float32(50.5), config.QPS)
+		assert.Equal(t, 100, config.Burst)
+		require.NotNil(t, config.WrapTransport)
+		wrapped, ok := config.WrapTransport(&http.Transport{}).(*http.Transport)
+		require.True(t, ok)
+		proxyURL, err := wrapped.Proxy(&http.Request{})
+		require.NoError(t, err)
+		assert.Equal(t, "http://proxy.com:3128", proxyURL.String())
+	})
+
+	t.Run("with insecure-skip-tls-verify annotation set to false", func(t *testing.T) {
+		// given
+		toolchainCluster, cl := newClusterWithAnnotations(t, map[string]string{cluster.InsecureSkipTLSVerifyAnnotation: "false"})
+
+		// when
+		config, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, config.Insecure)
+		assert.Equal(t, []byte("ca-bundle"), config.CAData)
+	})
+
+	t.Run("with invalid values", func(t *testing.T) {
+		for _, annotation := range []string{cluster.ProxyURLAnnotation, cluster.InsecureSkipTLSVerifyAnnotation, cluster.QPSAnnotation, cluster.BurstAnnotation} {
+			t.Run(annotation, func(t *testing.T) {
+				// given
+				toolchainCluster, cl := newClusterWithAnnotations(t, map[string]string{annotation: "%invalid"})
+
+				// when
+				_, err := cluster.NewClusterConfig(cl, toolchainCluster, 0)
+
+				// then
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid value for annotation \""+annotation+"\" of cluster east")
+			})
+		}
+	})
+}
@@ -1,10 +1,12 @@
 package cluster
 
 import (
+	"context"
 	"testing"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/h2non/gock.v1"
@@ -30,6 +32,8 @@ func TestRefreshCacheInService(t *testing.T) {
 		require.False(t, ok)
 		defer service.DeleteToolchainCluster("east")
 
+		refreshes := testutil.ToFloat64(cacheRefreshCounter)
+
 		// when
 		service.refreshCache()
 
@@ -37,6 +41,7 @@ func TestRefreshCacheInService(t *testing.T) {
 		cachedCluster, ok := GetCachedToolchainCluster(test.NameMember)
 		require.True(t, ok)
 		assertMemberCluster(t, cachedCluster, status)
+		assert.Equal(t, refreshes+1, testutil.ToFloat64(cacheRefreshCounter))
 	})
 
 	t.Run("the member cluster should be retrieved when GetCachedToolchainCluster func is called", func(t *testing.T) {
@@ -68,6 +73,100 @@ func TestRefreshCacheInService(t *testing.T) {
 	})
 }
 
+func TestAddOrUpdateToolchainClusterReusesClients(t *testing.T) {
+	// given
+	defer gock.Off()
+	status := test.NewClusterStatus(toolchainv1alpha1.ToolchainClusterReady, corev1.ConditionTrue)
+	toolchainCluster, sec := test.NewToolchainCluster("east", "secret", status, map[string]string{"ownerClusterName": test.NameMember})
+	s := scheme.Scheme
+	err := toolchainv1alpha1.AddToScheme(s)
+	require.NoError(t, err)
+	cl := test.NewFakeClient(t, toolchainCluster, sec)
+	service := NewToolchainClusterService(cl, logf.Log, "test-namespace", 0)
+	defer service.DeleteToolchainCluster("east")
+	err = service.AddOrUpdateToolchainCluster(toolchainCluster)
+	require.NoError(t, err)
+	previous, ok := GetCachedToolchainCluster("east")
+	require.True(t, ok)
+	previousClientset, err := previous.Clientset()
+	require.NoError(t, err)
+
+	t.Run("the clients should be reused when nothing changed", func(t *testing.T) {
+		// when
+		err := service.AddOrUpdateToolchainCluster(toolchainCluster)
+
+		// then
+		require.NoError(t, err)
+		cachedCluster, ok := GetCachedToolchainCluster("east")
+		require.True(t, ok)
+		assert.Same(t, previous.Config, cachedCluster.Config)
+		assert.Equal(t, previous.Client, cachedCluster.Client)
+		clientset, err := cachedCluster.Clientset()
+		require.NoError(t, err)
+		assert.Same(t, previousClientset, clientset)
+	})
+
+	t.Run("the clients should be created again when the secret changed", func(t *testing.T) {
+		// given
+		sec.Data["token"] = []byte("mynewtoken")
+		err := cl.Update(context.TODO(), sec)
+		require.NoError(t, err)
+
+		// when
+		err = service.AddOrUpdateToolchainCluster(toolchainCluster)
+
+		// then
+		require.NoError(t, err)
+		cachedCluster, ok := GetCachedToolchainCluster("east")
+		require.True(t, ok)
+		assert.Equal(t, "mynewtoken", cachedCluster.Config.BearerToken)
+		assert.NotSame(t, previous.Config, cachedCluster.Config)
+		clientset, err := cachedCluster.Clientset()
+		require.NoError(t, err)
+		assert.NotSame(t, previousClientset, clientset)
+	})
+}
+
+func TestAddOrUpdateToolchainClusterWithProxyReusesClients(t *testing.T) {
+	// given
+	defer gock.Off()
+	status := test.NewClusterStatus(toolchainv1alpha1.ToolchainClusterReady, corev1.ConditionTrue)
+	toolchainCluster, sec := test.NewToolchainCluster("east", "secret", status, map[string]string{"ownerClusterName": test.NameMember})
+	toolchainCluster.Annotations = map[string]string{ProxyURLAnnotation: "http://proxy.com:3128"}
+	cl := test.NewFakeClient(t, toolchainCluster, sec)
+	service := NewToolchainClusterService(cl, logf.Log, "test-namespace", 0)
+	defer service.DeleteToolchainCluster("east")
+	err := service.AddOrUpdateToolchainCluster(toolchainCluster)
+	require.NoError(t, err)
+	previous, ok := GetCachedToolchainCluster("east")
+	require.True(t, ok)
+
+	t.Run("the clients should be reused when the proxy didn't change", func(t *testing.T) {
+		// when
+		err := service.AddOrUpdateToolchainCluster(toolchainCluster)
+
+		// then
+		require.NoError(t, err)
+		cachedCluster, ok := GetCachedToolchainCluster("east")
+		require.True(t, ok)
+		assert.Same(t, previous.Config, cachedCluster.Config)
+	})
+
+	t.Run("the clients should be created again when the proxy changed", func(t *testing.T) {
+		// given
+		toolchainCluster.Annotations[ProxyURLAnnotation] = "http://other-proxy.com:3128"
+
+		// when
+		err := service.AddOrUpdateToolchainCluster(toolchainCluster)
+
+		// then
+		require.NoError(t, err)
+		cachedCluster, ok := GetCachedToolchainCluster("east")
+		require.True(t, ok)
+		assert.NotSame(t, previous.Config, cachedCluster.Config)
+	})
+}
+
 func assertMemberCluster(t *testing.T, cachedCluster *CachedToolchainCluster, status toolchainv1alpha1.ToolchainClusterStatus) {
 	assert.Equal(t, Member, cachedCluster.Type)
 	assert.Equal(t, status, *cachedCluster.ClusterStatus)
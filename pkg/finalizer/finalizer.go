@@ -0,0 +1,132 @@
+package finalizer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CleanupFunc the function to run when an object with a finalizer is being deleted, before the finalizer is removed
+type CleanupFunc func(ctx context.Context, obj runtime.Object) error
+
+// Has checks if the given object has the given finalizer
+func Has(obj runtime.Object, finalizer string) (bool, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+	}
+	return contains(accessor.GetFinalizers(), finalizer), nil
+}
+
+// Ensure adds the given finalizer to the given object if it's missing.
+// Returns `true` if the object was patched, `false` if the finalizer was already there.
+func Ensure(ctx context.Context, cl client.Client, obj runtime.Object, finalizer string) (bool, error) {
+	return patchFinalizers(ctx, cl, obj, func(finalizers []string) ([]string, bool) {
+		if contains(finalizers, finalizer) {
+			return finalizers, false
+		}
+		return append(finalizers, finalizer), true
+	})
+}
+
+// Remove removes the given finalizer from the given object if it's there.
+// Returns `true` if the object was patched, `false` if the finalizer was already missing.
+func Remove(ctx context.Context, cl client.Client, obj runtime.Object, finalizer string) (bool, error) {
+	return patchFinalizers(ctx, cl, obj, func(finalizers []string) ([]string, bool) {
+		if !contains(finalizers, finalizer) {
+			return finalizers, false
+		}
+		return remove(finalizers, finalizer), true
+	})
+}
+
+// Handle ensures that the given finalizer is set on the given object when the object is not being deleted.
+// When the object is being deleted and still has the finalizer, then it runs the given cleanup function and removes
+// the finalizer only if the cleanup succeeded, so the cleanup is run again on the next reconcile if it failed.
+// Returns `true` if the object is being deleted, so the caller can stop reconciling it.
+func Handle(ctx context.Context, cl client.Client, obj runtime.Object, finalizer string, cleanup CleanupFunc) (bool, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+	}
+	if accessor.GetDeletionTimestamp() == nil {
+		_, err := Ensure(ctx, cl, obj, finalizer)
+		return false, err
+	}
+	if !contains(accessor.GetFinalizers(), finalizer) {
+		return true, nil
+	}
+	if err := cleanup(ctx, obj); err != nil {
+		return true, errors.Wrapf(err, "unable to clean up the resource '%s' before removing the finalizer '%s'", accessor.GetName(), finalizer)
+	}
+	_, err = Remove(ctx, cl, obj, finalizer)
+	return true, err
+}
+
+// patchFinalizers patches the finalizers of the given object with the ones returned by the given function (if it returns `true`).
+// The patch contains the current resourceVersion of the object, so it fails with a conflict if the object was modified in the meantime.
+// In that case, the object is retrieved again and the patch is retried.
+func patchFinalizers(ctx context.Context, cl client.Client, obj runtime.Object, mutate func(finalizers []string) ([]string, bool)) (bool, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false, errors.Wrapf(err, "cannot get metadata from %+v", obj)
+	}
+	patched := false
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		finalizers, changed := mutate(append([]string{}, accessor.GetFinalizers()...))
+		if !changed {
+			return nil
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"finalizers":      finalizers,
+				"resourceVersion": accessor.GetResourceVersion(),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if err := cl.Patch(ctx, obj, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			if apierrors.IsConflict(err) {
+				// the finalizers are reset, since they wouldn't be if they were removed in the meantime
+				accessor.SetFinalizers(nil)
+				if err := cl.Get(ctx, types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}, obj); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+		patched = true
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to patch the finalizers of the resource '%s'", accessor.GetName())
+	}
+	return patched, nil
+}
+
+func contains(finalizers []string, finalizer string) bool {
+	for _, f := range finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(finalizers []string, finalizer string) []string {
+	result := make([]string, 0, len(finalizers))
+	for _, f := range finalizers {
+		if f != finalizer {
+			result = append(result, f)
+		}
+	}
+	return result
+}
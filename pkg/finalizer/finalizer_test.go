@@ -0,0 +1,211 @@
+package finalizer_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/finalizer"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testFinalizer = "finalizer.toolchain.dev.openshift.com"
+
+func TestEnsureAndRemove(t *testing.T) {
+
+	t.Run("typed object", func(t *testing.T) {
+		// given
+		userAccount := newUserAccount()
+		cl := test.NewFakeClient(t, userAccount)
+
+		// when
+		patched, err := finalizer.Ensure(context.TODO(), cl, userAccount, testFinalizer)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, patched)
+		assertFinalizers(t, cl, []string{"other", testFinalizer})
+
+		t.Run("should not patch when finalizer is already set", func(t *testing.T) {
+			// when
+			patched, err := finalizer.Ensure(context.TODO(), cl, userAccount, testFinalizer)
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, patched)
+		})
+
+		t.Run("should remove finalizer", func(t *testing.T) {
+			// when
+			patched, err := finalizer.Remove(context.TODO(), cl, userAccount, testFinalizer)
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, patched)
+			assertFinalizers(t, cl, []string{"other"})
+			has, err := finalizer.Has(userAccount, testFinalizer)
+			require.NoError(t, err)
+			assert.False(t, has)
+		})
+	})
+
+	t.Run("unstructured object", func(t *testing.T) {
+		// given
+		userAccount := newUserAccount()
+		cl := test.NewFakeClient(t, userAccount)
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(toolchainv1alpha1.GroupVersion.WithKind("UserAccount"))
+		err := cl.Get(context.TODO(), types.NamespacedName{Namespace: test.MemberOperatorNs, Name: "john"}, obj)
+		require.NoError(t, err)
+
+		// when
+		patched, err := finalizer.Ensure(context.TODO(), cl, obj, testFinalizer)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, patched)
+		assertFinalizers(t, cl, []string{"other", testFinalizer})
+		has, err := finalizer.Has(obj, testFinalizer)
+		require.NoError(t, err)
+		assert.True(t, has)
+	})
+
+	t.Run("should retry when there is a conflict", func(t *testing.T) {
+		// given
+		userAccount := newUserAccount()
+		cl := test.NewFakeClient(t, userAccount)
+		// the object is modified by someone else in the meantime
+		modified := userAccount.DeepCopy()
+		modified.Finalizers = []string{"another"}
+		err := cl.Update(context.TODO(), modified)
+		require.NoError(t, err)
+
+		// when
+		patched, err := finalizer.Ensure(context.TODO(), cl, userAccount, testFinalizer)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, patched)
+		assertFinalizers(t, cl, []string{"another", testFinalizer})
+	})
+
+	t.Run("should return error when patch fails", func(t *testing.T) {
+		// given
+		userAccount := newUserAccount()
+		cl := test.NewFakeClient(t, userAccount)
+		cl.MockPatch = func(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+			return fmt.Errorf("mock error")
+		}
+
+		// when
+		_, err := finalizer.Ensure(context.TODO(), cl, userAccount, testFinalizer)
+
+		// then
+		require.EqualError(t, err, "unable to patch the finalizers of the resource 'john': mock error")
+	})
+}
+
+func TestHandle(t *testing.T) {
+
+	t.Run("should add finalizer when object is not being deleted", func(t *testing.T) {
+		// given
+		userAccount := newUserAccount()
+		cl := test.NewFakeClient(t, userAccount)
+		cleanup := func(ctx context.Context, obj runtime.Object) error {
+			return fmt.Errorf("should not be called")
+		}
+
+		// when
+		deleting, err := finalizer.Handle(context.TODO(), cl, userAccount, testFinalizer, cleanup)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, deleting)
+		assertFinalizers(t, cl, []string{"other", testFinalizer})
+	})
+
+	t.Run("when object is being deleted", func(t *testing.T) {
+
+		t.Run("should clean up and remove finalizer", func(t *testing.T) {
+			// given
+			userAccount := newUserAccount(testFinalizer)
+			userAccount.DeletionTimestamp = &metav1.Time{}
+			cl := test.NewFakeClient(t, userAccount)
+			cleanedUp := false
+			cleanup := func(ctx context.Context, obj runtime.Object) error {
+				cleanedUp = true
+				return nil
+			}
+
+			// when
+			deleting, err := finalizer.Handle(context.TODO(), cl, userAccount, testFinalizer, cleanup)
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, deleting)
+			assert.True(t, cleanedUp)
+			assertFinalizers(t, cl, []string{"other"})
+		})
+
+		t.Run("should not remove finalizer when clean up fails", func(t *testing.T) {
+			// given
+			userAccount := newUserAccount(testFinalizer)
+			userAccount.DeletionTimestamp = &metav1.Time{}
+			cl := test.NewFakeClient(t, userAccount)
+			cleanup := func(ctx context.Context, obj runtime.Object) error {
+				return fmt.Errorf("mock error")
+			}
+
+			// when
+			deleting, err := finalizer.Handle(context.TODO(), cl, userAccount, testFinalizer, cleanup)
+
+			// then
+			require.EqualError(t, err, "unable to clean up the resource 'john' before removing the finalizer 'finalizer.toolchain.dev.openshift.com': mock error")
+			assert.True(t, deleting)
+			assertFinalizers(t, cl, []string{"other", testFinalizer})
+		})
+
+		t.Run("should not clean up when finalizer is already removed", func(t *testing.T) {
+			// given
+			userAccount := newUserAccount()
+			userAccount.DeletionTimestamp = &metav1.Time{}
+			cl := test.NewFakeClient(t, userAccount)
+			cleanup := func(ctx context.Context, obj runtime.Object) error {
+				return fmt.Errorf("should not be called")
+			}
+
+			// when
+			deleting, err := finalizer.Handle(context.TODO(), cl, userAccount, testFinalizer, cleanup)
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, deleting)
+		})
+	})
+}
+
+func newUserAccount(finalizers ...string) *toolchainv1alpha1.UserAccount {
+	return &toolchainv1alpha1.UserAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "john",
+			Namespace:  test.MemberOperatorNs,
+			Finalizers: append([]string{"other"}, finalizers...),
+		},
+	}
+}
+
+func assertFinalizers(t *testing.T, cl client.Client, expected []string) {
+	userAccount := &toolchainv1alpha1.UserAccount{}
+	err := cl.Get(context.TODO(), types.NamespacedName{Namespace: test.MemberOperatorNs, Name: "john"}, userAccount)
+	require.NoError(t, err)
+	assert.Equal(t, expected, userAccount.Finalizers)
+}
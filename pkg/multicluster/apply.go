@@ -0,0 +1,109 @@
+package multicluster
+
+import (
+	"context"
+	"sort"
+
+	"github.com/codeready-toolchain/toolchain-common/internal/concurrency"
+	commonclient "github.com/codeready-toolchain/toolchain-common/pkg/client"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// ClusterApplyResult the result of applying the objects to a single member cluster
+type ClusterApplyResult struct {
+	// ClusterName the name of the member cluster
+	ClusterName string
+	// CreatedOrUpdated is `true` when at least one of the objects was created or updated in the cluster
+	CreatedOrUpdated bool
+	// Err the error that occurred when applying the objects to the cluster
+	Err error
+}
+
+type applyConfiguration struct {
+	conditions     []cluster.Condition
+	maxConcurrency int
+}
+
+func newApplyConfiguration(options ...ApplyOption) applyConfiguration {
+	config := applyConfiguration{
+		maxConcurrency: concurrency.DefaultMaxConcurrency,
+	}
+	for _, apply := range options {
+		apply(&config)
+	}
+	return config
+}
+
+// ApplyOption an option when applying objects to several member clusters
+type ApplyOption func(*applyConfiguration)
+
+// MatchingClusters only applies the objects to the member clusters which match all the given conditions
+func MatchingClusters(conditions ...cluster.Condition) ApplyOption {
+	return func(config *applyConfiguration) {
+		config.conditions = append(config.conditions, conditions...)
+	}
+}
+
+// SkipNotReadyClusters only applies the objects to the member clusters which are Ready
+func SkipNotReadyClusters() ApplyOption {
+	return MatchingClusters(cluster.Ready)
+}
+
+// MaxConcurrentClusters sets the maximum number of clusters the objects are applied to concurrently (default: `5`)
+func MaxConcurrentClusters(maxConcurrency int) ApplyOption {
+	return func(config *applyConfiguration) {
+		if maxConcurrency > 0 {
+			config.maxConcurrency = maxConcurrency
+		}
+	}
+}
+
+// ApplyToolchainObjectsToMemberClusters applies the given objects with the given labels to all the member clusters from the cache
+// (or only to those matching the conditions set with the options), concurrently. Each cluster gets its own copy of the objects,
+// so the given objects are not modified.
+// Returns the results per cluster (sorted by cluster name) and an aggregated error of all the failures (or `nil`)
+func ApplyToolchainObjectsToMemberClusters(ctx context.Context, scheme *runtime.Scheme, toolchainObjects []commonclient.ToolchainObject, newLabels map[string]string, options ...ApplyOption) ([]ClusterApplyResult, error) {
+	config := newApplyConfiguration(options...)
+	memberClusters := cluster.MemberClusters(config.conditions...)
+	sort.Slice(memberClusters, func(i, j int) bool {
+		return memberClusters[i].Name < memberClusters[j].Name
+	})
+	results := make([]ClusterApplyResult, len(memberClusters))
+
+	concurrency.ForEach(len(memberClusters), config.maxConcurrency, func(index int) {
+		memberCluster := memberClusters[index]
+		results[index].ClusterName = memberCluster.Name
+		objs, err := copyToolchainObjects(toolchainObjects)
+		if err == nil {
+			results[index].CreatedOrUpdated, err = commonclient.NewApplyClient(memberCluster.Client, scheme).ApplyToolchainObjectsWithContext(ctx, objs, newLabels)
+		}
+		if err != nil {
+			results[index].Err = errors.Wrapf(err, "unable to apply the resources to the member cluster '%s'", memberCluster.Name)
+		}
+	})
+
+	var failures []error
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, result.Err)
+		}
+	}
+	return results, utilerrors.NewAggregate(failures)
+}
+
+// copyToolchainObjects returns deep copies of the given objects
+func copyToolchainObjects(toolchainObjects []commonclient.ToolchainObject) ([]commonclient.ToolchainObject, error) {
+	copies := make([]commonclient.ToolchainObject, len(toolchainObjects))
+	for i, toolchainObject := range toolchainObjects {
+		copied, err := commonclient.NewToolchainObject(toolchainObject.GetRuntimeObject().DeepCopyObject())
+		if err != nil {
+			return nil, err
+		}
+		copies[i] = copied
+	}
+	return copies, nil
+}
@@ -0,0 +1,146 @@
+package multicluster_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/client"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/multicluster"
+	. "github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestApplyToolchainObjectsToMemberClusters(t *testing.T) {
+	// given
+	s := scheme.Scheme
+	labels := map[string]string{
+		"toolchain.dev.openshift.com/provider": "codeready-toolchain",
+		"toolchain.dev.openshift.com/tier":     "basic",
+	}
+	newObjects := func(t *testing.T) []client.ToolchainObject {
+		configMap, err := client.NewToolchainObject(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "john-dev"},
+			Data:       map[string]string{"first-param": "first-value"},
+		})
+		require.NoError(t, err)
+		return []client.ToolchainObject{configMap}
+	}
+
+	t.Run("should apply to all member clusters", func(t *testing.T) {
+		// given
+		member1, member2 := newMemberCluster(t, "member-1", corev1.ConditionTrue), newMemberCluster(t, "member-2", corev1.ConditionFalse)
+		defer withMemberClusters(member2, member1)()
+		objs := newObjects(t)
+
+		// when
+		results, err := multicluster.ApplyToolchainObjectsToMemberClusters(context.TODO(), s, objs, labels)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, multicluster.ClusterApplyResult{ClusterName: "member-1", CreatedOrUpdated: true}, results[0])
+		assert.Equal(t, multicluster.ClusterApplyResult{ClusterName: "member-2", CreatedOrUpdated: true}, results[1])
+		assertConfigMapData(t, member1.Client, types.NamespacedName{Namespace: "john-dev", Name: "config"}, map[string]string{"first-param": "first-value"})
+		assertConfigMapData(t, member2.Client, types.NamespacedName{Namespace: "john-dev", Name: "config"}, map[string]string{"first-param": "first-value"})
+		// the given objects are not modified
+		assert.Empty(t, objs[0].GetLabels())
+	})
+
+	t.Run("should skip member clusters which are not ready", func(t *testing.T) {
+		// given
+		member1, member2 := newMemberCluster(t, "member-1", corev1.ConditionTrue), newMemberCluster(t, "member-2", corev1.ConditionFalse)
+		defer withMemberClusters(member1, member2)()
+
+		// when
+		results, err := multicluster.ApplyToolchainObjectsToMemberClusters(context.TODO(), s, newObjects(t), labels, multicluster.SkipNotReadyClusters())
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "member-1", results[0].ClusterName)
+		err = member2.Client.Get(context.TODO(), types.NamespacedName{Namespace: "john-dev", Name: "config"}, &corev1.ConfigMap{})
+		require.Error(t, err)
+	})
+
+	t.Run("should apply only to member clusters matching the condition", func(t *testing.T) {
+		// given
+		member1, member2 := newMemberCluster(t, "member-1", corev1.ConditionTrue), newMemberCluster(t, "member-2", corev1.ConditionTrue)
+		defer withMemberClusters(member1, member2)()
+
+		// when
+		results, err := multicluster.ApplyToolchainObjectsToMemberClusters(context.TODO(), s, newObjects(t), labels, multicluster.MatchingClusters(func(cluster *cluster.CachedToolchainCluster) bool {
+			return cluster.Name == "member-2"
+		}))
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "member-2", results[0].ClusterName)
+	})
+
+	t.Run("should return the failures per cluster", func(t *testing.T) {
+		// given
+		member1, member2 := newMemberCluster(t, "member-1", corev1.ConditionTrue), newMemberCluster(t, "member-2", corev1.ConditionTrue)
+		member2.Client.(*FakeClient).MockCreate = func(ctx context.Context, obj runtime.Object, opts ...runtimeclient.CreateOption) error {
+			return fmt.Errorf("mock error")
+		}
+		defer withMemberClusters(member1, member2)()
+
+		// when
+		results, err := multicluster.ApplyToolchainObjectsToMemberClusters(context.TODO(), s, newObjects(t), labels, multicluster.MaxConcurrentClusters(1))
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to apply the resources to the member cluster 'member-2'")
+		assert.Contains(t, err.Error(), "mock error")
+		require.Len(t, results, 2)
+		assert.NoError(t, results[0].Err)
+		assert.True(t, results[0].CreatedOrUpdated)
+		assert.Error(t, results[1].Err)
+		assert.False(t, results[1].CreatedOrUpdated)
+	})
+}
+
+func newMemberCluster(t *testing.T, name string, ready corev1.ConditionStatus) *cluster.CachedToolchainCluster {
+	status := NewClusterStatus(toolchainv1alpha1.ToolchainClusterReady, ready)
+	return &cluster.CachedToolchainCluster{
+		Name:          name,
+		Client:        NewFakeClient(t),
+		Type:          cluster.Member,
+		ClusterStatus: &status,
+	}
+}
+
+// withMemberClusters replaces the func that retrieves the member clusters, and returns the func to restore it
+func withMemberClusters(memberClusters ...*cluster.CachedToolchainCluster) func() {
+	original := cluster.MemberClusters
+	cluster.MemberClusters = func(conditions ...cluster.Condition) []*cluster.CachedToolchainCluster {
+		clusters := make(map[string]*cluster.CachedToolchainCluster, len(memberClusters))
+		for _, memberCluster := range memberClusters {
+			clusters[memberCluster.Name] = memberCluster
+		}
+		return cluster.Filter(cluster.Member, clusters, conditions...)
+	}
+	return func() {
+		cluster.MemberClusters = original
+	}
+}
+
+func assertConfigMapData(t *testing.T, cl runtimeclient.Client, namespacedName types.NamespacedName, expected map[string]string) {
+	configMap := &corev1.ConfigMap{}
+	err := cl.Get(context.TODO(), namespacedName, configMap)
+	require.NoError(t, err)
+	assert.Equal(t, expected, configMap.Data)
+}